@@ -0,0 +1,155 @@
+package bot
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	AckStatusDelivered = "DELIVERED"
+	AckStatusRead      = "READ"
+
+	acknowledgeBatchAction = "ACKNOWLEDGE_MESSAGE_RECEIPTS"
+	acknowledgeFlushWait   = 10 * time.Second
+)
+
+// Acknowledger coalesces message receipts and sends them in batches, when
+// MaxBatch receipts are queued or every Interval. The receipts are sent over
+// the Blaze connection when it's online, or posted to /acknowledgements.
+// Receipts failed to send are kept for the next flush.
+type Acknowledger struct {
+	MaxBatch int
+	Interval time.Duration
+
+	client *Client
+	blaze  *BlazeClient
+
+	mutex   sync.Mutex
+	pending map[string]string
+	order   []string
+	full    chan struct{}
+}
+
+func (c *Client) NewAcknowledger(maxBatch int, interval time.Duration) *Acknowledger {
+	return &Acknowledger{
+		MaxBatch: maxBatch,
+		Interval: interval,
+		client:   c,
+		pending:  make(map[string]string),
+		full:     make(chan struct{}, 1),
+	}
+}
+
+// NewAcknowledger makes Loop acknowledge the handled messages with a batch
+// acknowledger, which runs with each connection and is flushed before the
+// connection closes.
+func (b *BlazeClient) NewAcknowledger(maxBatch int, interval time.Duration) *Acknowledger {
+	a := b.client.NewAcknowledger(maxBatch, interval)
+	a.blaze = b
+	b.acknowledger = a
+	return a
+}
+
+// Ack queues a receipt, a READ receipt replaces a DELIVERED one of the same
+// message but not the reverse.
+func (a *Acknowledger) Ack(messageId, status string) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	a.add(messageId, status)
+	if a.MaxBatch > 0 && len(a.order) >= a.MaxBatch {
+		select {
+		case a.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (a *Acknowledger) add(messageId, status string) {
+	old, ok := a.pending[messageId]
+	if !ok {
+		a.order = append(a.order, messageId)
+	}
+	if old != AckStatusRead {
+		a.pending[messageId] = status
+	}
+}
+
+func (a *Acknowledger) Pending() int {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return len(a.order)
+}
+
+// Run flushes the receipts until ctx is done, then flushes the rest once
+// more before returning ctx.Err().
+func (a *Acknowledger) Run(ctx context.Context) error {
+	interval := a.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), acknowledgeFlushWait)
+			defer cancel()
+			if err := a.Flush(fctx); err != nil {
+				a.client.log().WarnContext(fctx, "flush acknowledgements", "pending", a.Pending(), "error", err)
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		case <-a.full:
+		}
+		if err := a.Flush(ctx); err != nil {
+			a.client.log().WarnContext(ctx, "flush acknowledgements", "pending", a.Pending(), "error", err)
+		}
+	}
+}
+
+// Flush sends all queued receipts in batches of MaxBatch.
+func (a *Acknowledger) Flush(ctx context.Context) error {
+	for {
+		batch := a.take()
+		if len(batch) == 0 {
+			return nil
+		}
+		if err := a.send(ctx, batch); err != nil {
+			a.mutex.Lock()
+			for _, r := range batch {
+				a.add(r.MessageId, r.Status)
+			}
+			a.mutex.Unlock()
+			return err
+		}
+	}
+}
+
+func (a *Acknowledger) take() []*ReceiptAcknowledgementRequest {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	n := len(a.order)
+	if a.MaxBatch > 0 {
+		n = min(n, a.MaxBatch)
+	}
+	batch := make([]*ReceiptAcknowledgementRequest, n)
+	for i, id := range a.order[:n] {
+		batch[i] = &ReceiptAcknowledgementRequest{MessageId: id, Status: a.pending[id]}
+		delete(a.pending, id)
+	}
+	a.order = a.order[n:]
+	return batch
+}
+
+func (a *Acknowledger) send(ctx context.Context, batch []*ReceiptAcknowledgementRequest) error {
+	if a.blaze != nil && a.blaze.online.Load() {
+		params := map[string]any{"messages": batch}
+		err := writeMessageAndWait(ctx, a.blaze.mc, acknowledgeBatchAction, params)
+		if err == nil {
+			return nil
+		}
+		a.client.log().WarnContext(ctx, "blaze acknowledgements", "size", len(batch), "error", err)
+	}
+	return a.client.PostAcknowledgements(ctx, batch)
+}
@@ -33,14 +33,20 @@ type SimpleAddress struct {
 	Tag         string `json:"tag"`
 }
 
+// CreateAddress takes a SafeUser because the address is signed with its spend
+// key.
 func CreateAddress(ctx context.Context, in *AddressInput, user *SafeUser) (*Address, error) {
+	return defaultClient.WithSafeUser(user).CreateAddress(ctx, in)
+}
+
+func (c *Client) CreateAddress(ctx context.Context, in *AddressInput) (*Address, error) {
 	tipBody := TipBodyForAddressAdd(in.AssetId, in.Destination, in.Tag, in.Label)
 	var err error
-	pin, err := signTipBody(tipBody, user.SpendPrivateKey, user.IsSpendPrivateSum)
+	pin, err := signTipBody(tipBody, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		return nil, err
 	}
-	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), user)
+	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +62,11 @@ func CreateAddress(ctx context.Context, in *AddressInput, user *SafeUser) (*Addr
 		return nil, err
 	}
 
-	token, err := SignAuthenticationToken("POST", "/addresses", string(data), user)
+	token, err := c.sign("POST", "/addresses", string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/addresses", data, token)
+	body, err := c.Request(ctx, "POST", "/addresses", data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -79,13 +85,17 @@ func CreateAddress(ctx context.Context, in *AddressInput, user *SafeUser) (*Addr
 	return resp.Data, nil
 }
 
-func ReadAddress(ctx context.Context, addressId string, user *SafeUser) (*Address, error) {
+func ReadAddress(ctx context.Context, addressId string, auth Authorizer) (*Address, error) {
+	return defaultClient.WithAuthorizer(auth).ReadAddress(ctx, addressId)
+}
+
+func (c *Client) ReadAddress(ctx context.Context, addressId string) (*Address, error) {
 	endpoint := fmt.Sprintf("/addresses/%s", addressId)
-	token, err := SignAuthenticationToken("GET", endpoint, "", user)
+	token, err := c.sign("GET", endpoint, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", endpoint, nil, token)
+	body, err := c.Request(ctx, "GET", endpoint, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -104,13 +114,19 @@ func ReadAddress(ctx context.Context, addressId string, user *SafeUser) (*Addres
 	return resp.Data, nil
 }
 
+// DeleteAddress takes a SafeUser because the removal is signed with its spend
+// key.
 func DeleteAddress(ctx context.Context, addressId string, user *SafeUser) error {
+	return defaultClient.WithSafeUser(user).DeleteAddress(ctx, addressId)
+}
+
+func (c *Client) DeleteAddress(ctx context.Context, addressId string) error {
 	tipBody := TipBody(TIPAddressRemove + addressId)
-	pin, err := signTipBody(tipBody, user.SpendPrivateKey, user.IsSpendPrivateSum)
+	pin, err := signTipBody(tipBody, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		return err
 	}
-	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), user)
+	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return err
 	}
@@ -122,11 +138,11 @@ func DeleteAddress(ctx context.Context, addressId string, user *SafeUser) error
 	}
 
 	endpoint := fmt.Sprintf("/addresses/%s/delete", addressId)
-	token, err := SignAuthenticationToken("POST", endpoint, string(data), user)
+	token, err := c.sign("POST", endpoint, string(data))
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, "POST", endpoint, data, token)
+	body, err := c.Request(ctx, "POST", endpoint, data, token)
 	if err != nil {
 		return err
 	}
@@ -144,13 +160,17 @@ func DeleteAddress(ctx context.Context, addressId string, user *SafeUser) error
 	return nil
 }
 
-func GetAddressesByAssetId(ctx context.Context, assetId string, user *SafeUser) ([]*Address, error) {
+func GetAddressesByAssetId(ctx context.Context, assetId string, auth Authorizer) ([]*Address, error) {
+	return defaultClient.WithAuthorizer(auth).GetAddressesByAssetId(ctx, assetId)
+}
+
+func (c *Client) GetAddressesByAssetId(ctx context.Context, assetId string) ([]*Address, error) {
 	endpoint := fmt.Sprintf("/assets/%s/addresses", assetId)
-	token, err := SignAuthenticationToken("GET", endpoint, "", user)
+	token, err := c.sign("GET", endpoint, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", endpoint, nil, token)
+	body, err := c.Request(ctx, "GET", endpoint, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +190,10 @@ func GetAddressesByAssetId(ctx context.Context, assetId string, user *SafeUser)
 }
 
 func CheckAddress(ctx context.Context, asset, destination, tag string) (*SimpleAddress, error) {
+	return defaultClient.CheckAddress(ctx, asset, destination, tag)
+}
+
+func (c *Client) CheckAddress(ctx context.Context, asset, destination, tag string) (*SimpleAddress, error) {
 	v := url.Values{}
 	v.Set("asset", asset)
 	v.Set("destination", destination)
@@ -177,7 +201,7 @@ func CheckAddress(ctx context.Context, asset, destination, tag string) (*SimpleA
 		v.Set("tag", tag)
 	}
 	path := "/external/addresses/check?" + v.Encode()
-	body, err := Request(ctx, "GET", path, nil, "")
+	body, err := c.Request(ctx, "GET", path, nil, "")
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
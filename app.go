@@ -24,13 +24,19 @@ type App struct {
 	IsVerified       bool      `json:"is_verified"`
 }
 
+// Migrate transfers the app of user to receiver, it takes a SafeUser because
+// the transfer is signed with its spend key.
 func Migrate(ctx context.Context, receiver string, user *SafeUser) (*App, error) {
+	return defaultClient.WithSafeUser(user).Migrate(ctx, receiver)
+}
+
+func (c *Client) Migrate(ctx context.Context, receiver string) (*App, error) {
 	tipBody := TipBodyForOwnershipTransfer(receiver)
-	pin, err := signTipBody(tipBody, user.SpendPrivateKey, user.IsSpendPrivateSum)
+	pin, err := signTipBody(tipBody, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		return nil, err
 	}
-	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), user)
+	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -42,12 +48,12 @@ func Migrate(ctx context.Context, receiver string, user *SafeUser) (*App, error)
 		return nil, err
 	}
 
-	path := fmt.Sprintf("/apps/%s/transfer", uid)
-	token, err := SignAuthenticationToken("POST", path, string(data), user)
+	path := fmt.Sprintf("/apps/%s/transfer", c.user.UserId)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"maps"
 	"net/url"
 	"slices"
@@ -84,14 +83,27 @@ func AssetBalance(ctx context.Context, assetId, uid, sid, sessionKey string) (co
 	return AssetBalanceWithSafeUser(ctx, assetId, su)
 }
 
+// Deprecated: use AssetBalanceWithAuthorizer.
 func AssetBalanceWithSafeUser(ctx context.Context, assetId string, su *SafeUser) (common.Integer, error) {
+	return AssetBalanceWithAuthorizer(ctx, assetId, su)
+}
+
+func AssetBalanceWithAuthorizer(ctx context.Context, assetId string, auth Authorizer) (common.Integer, error) {
+	return defaultClient.WithAuthorizer(auth).AssetBalance(ctx, assetId)
+}
+
+func (c *Client) AssetBalance(ctx context.Context, assetId string) (common.Integer, error) {
+	userId, err := c.userId(ctx)
+	if err != nil {
+		return common.Zero, err
+	}
 	offset := int64(0)
 	filter := make(map[string]bool)
 	var total common.Integer
 	for {
-		outputs, err := ListOutputs(ctx, HashMembers([]string{su.UserId}), 1, assetId, OutputStateUnspent, offset, 500, su)
+		outputs, err := c.ListOutputs(ctx, HashMembers([]string{userId}), 1, assetId, OutputStateUnspent, offset, 500)
 		if err != nil {
-			log.Println(err)
+			c.log().WarnContext(ctx, "list outputs", "asset", assetId, "offset", offset, "error", err)
 			continue
 		}
 		for i, o := range outputs {
@@ -111,9 +123,10 @@ func AssetBalanceWithSafeUser(ctx context.Context, assetId string, su *SafeUser)
 	return total, nil
 }
 
+// Deprecated: use AssetBalanceWithAuthorizer with BearerToken(accessToken).
 func UserAssetBalance(ctx context.Context, userID, assetId, accessToken string) (common.Integer, error) {
 	membersHash := HashMembers([]string{userID})
-	outputs, err := ListUnspentOutputsByToken(ctx, membersHash, 1, assetId, accessToken)
+	outputs, err := ListUnspentOutputs(ctx, membersHash, 1, assetId, BearerToken(accessToken))
 	if err != nil {
 		return common.Zero, err
 	}
@@ -125,15 +138,19 @@ func UserAssetBalance(ctx context.Context, userID, assetId, accessToken string)
 	return total, nil
 }
 
-func ReadAssetFee(ctx context.Context, assetId, destination string, su *SafeUser) ([]*AssetFee, error) {
+func ReadAssetFee(ctx context.Context, assetId, destination string, auth Authorizer) ([]*AssetFee, error) {
+	return defaultClient.WithAuthorizer(auth).ReadAssetFee(ctx, assetId, destination)
+}
+
+func (c *Client) ReadAssetFee(ctx context.Context, assetId, destination string) ([]*AssetFee, error) {
 	params := url.Values{}
 	params.Set("destination", destination)
 	method, path := "GET", fmt.Sprintf("/safe/assets/%s/fees?%s", assetId, params.Encode())
-	token, err := SignAuthenticationToken(method, path, "", su)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, nil, token)
+	body, err := c.Request(ctx, method, path, nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -151,18 +168,22 @@ func ReadAssetFee(ctx context.Context, assetId, destination string, su *SafeUser
 	return resp.Data, nil
 }
 
-func FetchAssets(ctx context.Context, assetIds []string, safeUser *SafeUser) ([]*Asset, error) {
+func FetchAssets(ctx context.Context, assetIds []string, auth Authorizer) ([]*Asset, error) {
+	return defaultClient.WithAuthorizer(auth).FetchAssets(ctx, assetIds)
+}
+
+func (c *Client) FetchAssets(ctx context.Context, assetIds []string) ([]*Asset, error) {
 	body, err := json.Marshal(assetIds)
 	if err != nil {
 		return nil, err
 	}
 
 	path := "/safe/assets/fetch"
-	token, err := SignAuthenticationToken("POST", path, string(body), safeUser)
+	token, err := c.sign("POST", path, string(body))
 	if err != nil {
 		return nil, err
 	}
-	result, err := Request(ctx, "POST", path, body, token)
+	result, err := c.Request(ctx, "POST", path, body, token)
 	if err != nil {
 		return nil, err
 	}
@@ -180,15 +201,23 @@ func FetchAssets(ctx context.Context, assetIds []string, safeUser *SafeUser) ([]
 	return resp.Data, nil
 }
 
-func ListAssetWithBalance(ctx context.Context, su *SafeUser) ([]*Asset, error) {
-	membersHash := HashMembers([]string{su.UserId})
+func ListAssetWithBalance(ctx context.Context, auth Authorizer) ([]*Asset, error) {
+	return defaultClient.WithAuthorizer(auth).ListAssetWithBalance(ctx)
+}
+
+func (c *Client) ListAssetWithBalance(ctx context.Context) ([]*Asset, error) {
+	userId, err := c.userId(ctx)
+	if err != nil {
+		return nil, err
+	}
+	membersHash := HashMembers([]string{userId})
 	offset := int64(0)
 	m := make(map[string]number.Decimal)
 	filter := make(map[string]bool)
 	for {
-		outputs, err := ListOutputs(ctx, membersHash, 1, "", OutputStateUnspent, offset, 500, su)
+		outputs, err := c.ListOutputs(ctx, membersHash, 1, "", OutputStateUnspent, offset, 500)
 		if err != nil {
-			log.Println(err)
+			c.log().WarnContext(ctx, "list outputs", "offset", offset, "error", err)
 			continue
 		}
 		for i, output := range outputs {
@@ -210,10 +239,9 @@ func ListAssetWithBalance(ctx context.Context, su *SafeUser) ([]*Asset, error) {
 		}
 	}
 	assets := []*Asset{}
-	var err error
 	if len(m) > 0 {
 		assetIds := slices.Collect(maps.Keys(m))
-		assets, err = FetchAssets(ctx, assetIds, su)
+		assets, err = c.FetchAssets(ctx, assetIds)
 		if err != nil {
 			return nil, err
 		}
@@ -1,8 +1,17 @@
 package bot
 
 import (
+	"bytes"
 	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
 )
 
 type Attachment struct {
@@ -12,12 +21,16 @@ type Attachment struct {
 	UploadUrl    string `json:"upload_url"`
 }
 
-func CreateAttachment(ctx context.Context, user *SafeUser) (*Attachment, error) {
-	token, err := SignAuthenticationToken("POST", "/attachments", "", user)
+func CreateAttachment(ctx context.Context, auth Authorizer) (*Attachment, error) {
+	return defaultClient.WithAuthorizer(auth).CreateAttachment(ctx)
+}
+
+func (c *Client) CreateAttachment(ctx context.Context) (*Attachment, error) {
+	token, err := c.sign("POST", "/attachments", "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/attachments", nil, token)
+	body, err := c.Request(ctx, "POST", "/attachments", nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -40,12 +53,16 @@ func CreateAttachment(ctx context.Context, user *SafeUser) (*Attachment, error)
 	return &resp.Data, nil
 }
 
-func AttachmentShow(ctx context.Context, id string, user *SafeUser) (*Attachment, error) {
-	token, err := SignAuthenticationToken("GET", "/attachments/"+id, "", user)
+func AttachmentShow(ctx context.Context, id string, auth Authorizer) (*Attachment, error) {
+	return defaultClient.WithAuthorizer(auth).AttachmentShow(ctx, id)
+}
+
+func (c *Client) AttachmentShow(ctx context.Context, id string) (*Attachment, error) {
+	token, err := c.sign("GET", "/attachments/"+id, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", "/attachments/"+id, nil, token)
+	body, err := c.Request(ctx, "GET", "/attachments/"+id, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -67,3 +84,141 @@ func AttachmentShow(ctx context.Context, id string, user *SafeUser) (*Attachment
 	}
 	return &resp.Data, nil
 }
+
+// AttachmentUpload is an uploaded attachment, Key and Digest are set when the
+// bytes are encrypted.
+type AttachmentUpload struct {
+	AttachmentId string
+	MimeType     string
+	Size         int64
+	Key          []byte
+	Digest       []byte
+}
+
+// UploadAttachment creates an attachment and uploads the bytes of r, which
+// are encrypted when the encryption of the client is enabled.
+func UploadAttachment(ctx context.Context, r io.Reader, mime string, auth Authorizer) (*AttachmentUpload, error) {
+	return defaultClient.WithAuthorizer(auth).UploadAttachment(ctx, r, mime)
+}
+
+func (c *Client) UploadAttachment(ctx context.Context, r io.Reader, mime string) (*AttachmentUpload, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if mime == "" {
+		mime = http.DetectContentType(data)
+	}
+	upload := &AttachmentUpload{MimeType: mime, Size: int64(len(data))}
+	if c.encrypt {
+		data, upload.Key, upload.Digest, err = EncryptAttachment(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	a, err := c.CreateAttachment(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "PUT", a.UploadUrl, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("x-amz-acl", "public-read")
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ServerError(ctx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ServerError(ctx, fmt.Errorf("upload attachment %s status %d", a.AttachmentId, resp.StatusCode))
+	}
+	upload.AttachmentId = a.AttachmentId
+	return upload, nil
+}
+
+// DownloadAttachment returns the bytes of the attachment as uploaded, use
+// DecryptAttachment with the key and digest of the message when present.
+func DownloadAttachment(ctx context.Context, id string, auth Authorizer) ([]byte, error) {
+	return defaultClient.WithAuthorizer(auth).DownloadAttachment(ctx, id)
+}
+
+func (c *Client) DownloadAttachment(ctx context.Context, id string) ([]byte, error) {
+	a, err := c.AttachmentShow(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", a.ViewURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, ServerError(ctx, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ServerError(ctx, fmt.Errorf("download attachment %s status %d", id, resp.StatusCode))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// EncryptAttachment encrypts data with a random AES-256-CBC key and signs it
+// with a random HMAC-SHA256 key, the blob is iv || ciphertext || mac. The key
+// is the AES key followed by the HMAC key, and the digest is the SHA256 of
+// the blob.
+func EncryptAttachment(data []byte) (blob, key, digest []byte, err error) {
+	key = make([]byte, 64)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, nil, err
+	}
+	block, err := aes.NewCipher(key[:32])
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	padding := aes.BlockSize - len(data)%aes.BlockSize
+	plain := append(bytes.Clone(data), bytes.Repeat([]byte{byte(padding)}, padding)...)
+	blob = make([]byte, aes.BlockSize+len(plain), aes.BlockSize+len(plain)+sha256.Size)
+	if _, err := rand.Read(blob[:aes.BlockSize]); err != nil {
+		return nil, nil, nil, err
+	}
+	cipher.NewCBCEncrypter(block, blob[:aes.BlockSize]).CryptBlocks(blob[aes.BlockSize:], plain)
+	mac := hmac.New(sha256.New, key[32:])
+	mac.Write(blob)
+	blob = mac.Sum(blob)
+	sum := sha256.Sum256(blob)
+	return blob, key, sum[:], nil
+}
+
+func DecryptAttachment(blob, key, digest []byte) ([]byte, error) {
+	if len(key) != 64 {
+		return nil, fmt.Errorf("invalid attachment key size %d", len(key))
+	}
+	if len(blob) < aes.BlockSize*2+sha256.Size || (len(blob)-sha256.Size)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("invalid attachment size %d", len(blob))
+	}
+	if len(digest) > 0 {
+		sum := sha256.Sum256(blob)
+		if !hmac.Equal(sum[:], digest) {
+			return nil, fmt.Errorf("invalid attachment digest")
+		}
+	}
+	body, sig := blob[:len(blob)-sha256.Size], blob[len(blob)-sha256.Size:]
+	mac := hmac.New(sha256.New, key[32:])
+	mac.Write(body)
+	if !hmac.Equal(mac.Sum(nil), sig) {
+		return nil, fmt.Errorf("invalid attachment mac")
+	}
+	block, err := aes.NewCipher(key[:32])
+	if err != nil {
+		return nil, err
+	}
+	plain := make([]byte, len(body)-aes.BlockSize)
+	cipher.NewCBCDecrypter(block, body[:aes.BlockSize]).CryptBlocks(plain, body[aes.BlockSize:])
+	padding := int(plain[len(plain)-1])
+	if padding < 1 || padding > aes.BlockSize {
+		return nil, fmt.Errorf("invalid attachment padding")
+	}
+	return plain[:len(plain)-padding], nil
+}
@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttachmentEncryption(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, size := range []int{0, 15, 16, 1000} {
+		data := bytes.Repeat([]byte{7}, size)
+		blob, key, digest, err := EncryptAttachment(data)
+		assert.Nil(err)
+		assert.Len(key, 64)
+		assert.Len(blob, 16+(size/16+1)*16+32)
+		plain, err := DecryptAttachment(blob, key, digest)
+		assert.Nil(err)
+		assert.Equal(data, plain)
+	}
+
+	blob, key, digest, _ := EncryptAttachment([]byte("hello"))
+	_, err := DecryptAttachment(blob, key, digest[1:])
+	assert.ErrorContains(err, "digest")
+	blob[20] ^= 1
+	_, err = DecryptAttachment(blob, key, nil)
+	assert.ErrorContains(err, "mac")
+}
+
+func TestImageThumbnail(t *testing.T) {
+	assert := assert.New(t)
+
+	img := image.NewRGBA(image.Rect(0, 0, 640, 320))
+	img.Set(0, 0, color.White)
+	thumbnail, err := imageThumbnail(img)
+	assert.Nil(err)
+	data, err := base64.StdEncoding.DecodeString(thumbnail)
+	assert.Nil(err)
+	cfg, err := jpeg.DecodeConfig(bytes.NewReader(data))
+	assert.Nil(err)
+	assert.Equal(64, cfg.Width)
+	assert.Equal(32, cfg.Height)
+}
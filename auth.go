@@ -69,6 +69,12 @@ func SignOauthAccessToken(appID, authorizationID, privateKey, method, uri, body,
 // OAuthGetAccessToken get the access token of a user
 // ed25519 is optional, only use it when you want to sign OAuth access token locally
 func OAuthGetAccessToken(ctx context.Context, clientID, clientSecret string, authorizationCode string, codeVerifier string, ed25519 string) (string, string, string, error) {
+	return defaultClient.OAuthGetAccessToken(ctx, clientID, clientSecret, authorizationCode, codeVerifier, ed25519)
+}
+
+// OAuthGetAccessToken get the access token of a user
+// ed25519 is optional, only use it when you want to sign OAuth access token locally
+func (c *Client) OAuthGetAccessToken(ctx context.Context, clientID, clientSecret, authorizationCode, codeVerifier, ed25519 string) (string, string, string, error) {
 	params, err := json.Marshal(map[string]string{
 		"client_id":     clientID,
 		"client_secret": clientSecret,
@@ -79,7 +85,7 @@ func OAuthGetAccessToken(ctx context.Context, clientID, clientSecret string, aut
 	if err != nil {
 		return "", "", "", BadDataError(ctx)
 	}
-	body, err := Request(ctx, "POST", "/oauth/token", params, "")
+	body, err := c.Request(ctx, "POST", "/oauth/token", params, "")
 	if err != nil {
 		return "", "", "", ServerError(ctx, err)
 	}
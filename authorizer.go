@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+)
+
+// Authorizer signs the access token of an API request, it's implemented by
+// *SafeUser for the session of a bot or user, *OAuthToken for a user who
+// authorized an app with OAuth, and BearerToken for a token signed elsewhere.
+// The functions signing a TIP body or a transaction with the spend key still
+// take a *SafeUser.
+type Authorizer interface {
+	AccessToken(method, uri, body string) (string, error)
+}
+
+// BearerToken is an access token used as is for any request, e.g. one signed
+// by another service for a single request.
+type BearerToken string
+
+func (t BearerToken) AccessToken(method, uri, body string) (string, error) {
+	return string(t), nil
+}
+
+func (su *SafeUser) AccessToken(method, uri, body string) (string, error) {
+	return SignAuthenticationToken(method, uri, body, su)
+}
+
+func (t *OAuthToken) AccessToken(method, uri, body string) (string, error) {
+	return SignOauthAccessToken(t.ClientId, t.AuthorizationId, t.PrivateKey, method, uri, body, t.Scope, UuidNewV4().String())
+}
+
+func (a *Authenticator) AccessToken(method, uri, body string) (string, error) {
+	return a.BuildJWT(method, uri, body)
+}
+
+// WithAuthorizer returns a shallow copy of the client signing its requests
+// with auth, a *SafeUser also replaces the default user. Otherwise the default
+// user is kept for the methods using its keys, e.g. to spend or decrypt.
+func (c *Client) WithAuthorizer(auth Authorizer) *Client {
+	if su, ok := auth.(*SafeUser); ok {
+		return c.WithSafeUser(su)
+	}
+	n := *c
+	n.auth = auth
+	return &n
+}
+
+// WithoutAuthorizer returns a copy of the client sending unsigned requests,
+// for the public endpoints such as /network/snapshots.
+func (c *Client) WithoutAuthorizer() *Client {
+	n := *c
+	n.user = nil
+	n.auth = nil
+	return &n
+}
+
+func (c *Client) Authorizer() Authorizer {
+	return c.auth
+}
+
+func (c *Client) sign(method, uri, body string) (string, error) {
+	if c.auth == nil {
+		return "", fmt.Errorf("no authorizer for %s %s", method, uri)
+	}
+	return c.auth.AccessToken(method, uri, body)
+}
+
+// userId returns the id of the user signing the requests of the client, an
+// OAuth or bearer token is resolved with /safe/me.
+func (c *Client) userId(ctx context.Context) (string, error) {
+	switch auth := c.auth.(type) {
+	case nil:
+		return "", fmt.Errorf("no authorizer")
+	case *SafeUser:
+		return auth.UserId, nil
+	case *Authenticator:
+		return auth.Uid, nil
+	}
+	me, err := c.RequestUserMe(ctx)
+	if err != nil {
+		return "", err
+	}
+	return me.UserId, nil
+}
+
+// authorizer returns su as an Authorizer, a nil *SafeUser is a nil Authorizer.
+func authorizer(su *SafeUser) Authorizer {
+	if su == nil {
+		return nil
+	}
+	return su
+}
@@ -0,0 +1,62 @@
+package bot
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthorizer(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var tokens []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens = append(tokens, r.Header.Get("Authorization"))
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer s.Close()
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	seed := hex.EncodeToString(key.Seed())
+	su := &SafeUser{UserId: "u", SessionId: "s", SessionPrivateKey: seed}
+	oauth := &OAuthToken{ClientId: "c", AuthorizationId: "a", Scope: "PROFILE:READ", PrivateKey: seed}
+
+	c := NewClient(nil)
+	c.SetBaseUri(s.URL)
+	_, err := c.SafeSnapshots(ctx, 10, "", "", "", "")
+	assert.ErrorContains(err, "no authorizer")
+	for _, auth := range []Authorizer{su, oauth, BearerToken("token")} {
+		_, err := c.WithAuthorizer(auth).SafeSnapshots(ctx, 10, "", "", "", "")
+		assert.Nil(err)
+	}
+	_, err = c.WithAuthorizer(BearerToken("legacy")).SafeSnapshots(ctx, 10, "", "", "", "")
+	assert.Nil(err)
+	assert.Len(tokens, 4)
+	assert.Equal("Bearer token", tokens[2])
+	assert.Equal("Bearer legacy", tokens[3])
+
+	claims := jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tokens[0][7:], claims)
+	assert.Nil(err)
+	assert.Equal("u", claims["uid"])
+	claims = jwt.MapClaims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tokens[1][7:], claims)
+	assert.Nil(err)
+	assert.Equal("a", claims["aid"])
+	assert.Equal("PROFILE:READ", claims["scp"])
+
+	// the default user is kept unless replaced by another SafeUser
+	c = c.WithSafeUser(su)
+	assert.Equal(su, c.WithAuthorizer(oauth).SafeUser())
+	other := &SafeUser{UserId: "o"}
+	assert.Equal(other, c.WithAuthorizer(other).SafeUser())
+	assert.Equal(Authorizer(other), c.WithAuthorizer(other).Authorizer())
+}
@@ -1,19 +1,25 @@
 package bot
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,14 +28,20 @@ const (
 	pongWait        = 10 * time.Second
 	pingPeriod      = (pongWait * 9) / 10
 
+	defaultBlazeShutdownTimeout = 10 * time.Second
+
 	createMessageAction = "CREATE_MESSAGE"
 	maximumButtons      = 18
 )
 
+var errBlazeActionTimeout = errors.New("blaze action timeout")
+
 const (
 	MessageCategoryPlainText             = "PLAIN_TEXT"
 	MessageCategoryPlainImage            = "PLAIN_IMAGE"
 	MessageCategoryPlainData             = "PLAIN_DATA"
+	MessageCategoryPlainAudio            = "PLAIN_AUDIO"
+	MessageCategoryPlainVideo            = "PLAIN_VIDEO"
 	MessageCategoryPlainSticker          = "PLAIN_STICKER"
 	MessageCategoryPlainLive             = "PLAIN_LIVE"
 	MessageCategoryPlainContact          = "PLAIN_CONTACT"
@@ -103,11 +115,13 @@ type AppButtonView struct {
 type AppCardAction = AppButtonView
 
 type AppCardView struct {
-	AppID       string          `json:"app_id"`
-	CoverURL    string          `json:"cover_url"`
+	AppID       string          `json:"app_id,omitempty"`
+	IconURL     string          `json:"icon_url,omitempty"`
+	CoverURL    string          `json:"cover_url,omitempty"`
 	Title       string          `json:"title"`
 	Description string          `json:"description"`
-	Actions     []AppCardAction `json:"actions"`
+	Action      string          `json:"action,omitempty"`
+	Actions     []AppCardAction `json:"actions,omitempty"`
 	Shareable   bool            `json:"shareable"`
 }
 
@@ -117,6 +131,20 @@ type messageContext struct {
 	writeDone    chan bool
 	readBuffer   chan MessageView
 	writeBuffer  chan []byte
+	telemetry    *telemetry
+	logger       *slog.Logger
+	timeout      time.Duration
+	retry        *RetryPolicy
+	// budget limits the messages read but not yet handled, it's shared by
+	// the bots of a hub
+	budget chan struct{}
+}
+
+func (mc *messageContext) log() *slog.Logger {
+	if mc.logger != nil {
+		return mc.logger
+	}
+	return slog.New(NewRedactHandler(slog.Default().Handler()))
 }
 
 type SystemConversationPayload struct {
@@ -127,11 +155,23 @@ type SystemConversationPayload struct {
 }
 
 type BlazeClient struct {
-	mc     *messageContext
-	uid    string
-	sid    string
-	key    string
-	dailer *websocket.Dialer
+	mc   *messageContext
+	uid  string
+	sid  string
+	key  string
+	host string
+	dial BlazeDialer
+
+	client       *Client
+	acknowledger *Acknowledger
+	outbox       *outbox
+	dedup        DedupStore
+	shutdown     time.Duration
+	status       *messageStatus
+	online       atomic.Bool
+	connects     int
+	workers      int
+	queueSize    int
 }
 
 type BlazeListener interface {
@@ -141,223 +181,368 @@ type BlazeListener interface {
 }
 
 func NewBlazeClientWithSafeUser(user *SafeUser) *BlazeClient {
-	return NewBlazeClient(user.UserId, user.SessionId, user.SessionPrivateKey)
+	return defaultClient.WithSafeUser(user).NewBlazeClient()
 }
 
 func NewBlazeClient(uid, sid, key string) *BlazeClient {
+	return NewBlazeClientWithSafeUser(&SafeUser{
+		UserId:            uid,
+		SessionId:         sid,
+		SessionPrivateKey: key,
+	})
+}
+
+func (c *Client) NewBlazeClient() *BlazeClient {
+	return c.newBlazeClient(102400)
+}
+
+func (c *Client) newBlazeClient(bufferSize int) *BlazeClient {
 	client := BlazeClient{
 		mc: &messageContext{
 			transactions: newTmap(),
 			readDone:     make(chan bool, 1),
 			writeDone:    make(chan bool, 1),
-			readBuffer:   make(chan MessageView, 102400),
-			writeBuffer:  make(chan []byte, 102400),
+			readBuffer:   make(chan MessageView, bufferSize),
+			writeBuffer:  make(chan []byte, bufferSize),
+			telemetry:    c.telemetry,
+			logger:       c.logger,
+			timeout:      keepAlivePeriod,
+			retry:        c.retry,
 		},
-		uid: uid,
-		sid: sid,
-		key: key,
+		uid:      c.user.UserId,
+		sid:      c.user.SessionId,
+		key:      c.user.SessionPrivateKey,
+		host:     c.blazeUri,
+		client:   c.WithSafeUser(c.user),
+		status:   newMessageStatus(),
+		shutdown: defaultBlazeShutdownTimeout,
+	}
+	if client.mc.retry == nil {
+		client.mc.retry = &RetryPolicy{
+			MaxAttempts: 5,
+			MinBackoff:  100 * time.Millisecond,
+			MaxBackoff:  2 * time.Second,
+		}
 	}
 	client.SetupDailer(nil)
 	return &client
 }
 
+// SetupDailer makes the client connect with gorilla/websocket and dailer.
 func (b *BlazeClient) SetupDailer(dailer *websocket.Dialer) {
-	if dailer == nil {
-		dailer = &websocket.Dialer{}
-	}
-	dailer.Subprotocols = []string{"Mixin-Blaze-1"}
-	if dailer.HandshakeTimeout == 0 {
-		dailer.HandshakeTimeout = time.Second * 5
+	b.dial = GorillaBlazeDialer(dailer)
+}
+
+func (b *BlazeClient) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		b.mc.logger = nil
+		return
 	}
-	b.dailer = dailer
+	b.mc.logger = slog.New(NewRedactHandler(logger.Handler()))
+}
+
+// SetActionTimeout sets how long an action waits to be written and then for
+// its reply, 3 seconds by default.
+func (b *BlazeClient) SetActionTimeout(timeout time.Duration) {
+	b.mc.timeout = timeout
 }
 
+// SetActionRetryPolicy sets the retries of the actions failed with an error
+// reply or a timeout, the retry policy of the client or 5 attempts by
+// default. A nil policy disables retries.
+func (b *BlazeClient) SetActionRetryPolicy(p *RetryPolicy) {
+	b.mc.retry = p
+}
+
+// SetShutdownTimeout sets how long the handlers in progress are given to
+// return once the context of Loop is done, 10 seconds by default.
+func (b *BlazeClient) SetShutdownTimeout(timeout time.Duration) {
+	b.shutdown = timeout
+}
+
+// Loop handles the messages until the connection is lost or ctx is done. When
+// ctx is done, the handlers in progress are drained up to the shutdown timeout
+// and the pending receipts flushed, before the connection is closed with a
+// close frame and ctx.Err() is returned.
 func (b *BlazeClient) Loop(ctx context.Context, listener BlazeListener) error {
-	conn, err := b.connectMixinBlaze()
+	return b.loop(ctx, listener, nil)
+}
+
+// loop serves one connection, connected is called once the pending messages
+// have been listed. Transactions still waiting for a reply when it returns
+// are failed, so that their waiters don't leak.
+func (b *BlazeClient) loop(ctx context.Context, listener BlazeListener, connected func()) error {
+	conn, err := b.connectMixinBlaze(ctx)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
-	go writePump(ctx, conn, b.mc)
-	go readPump(ctx, conn, b.mc)
+	defer b.mc.transactions.clear()
+	// the messages left by the connection are not acknowledged, and will be
+	// listed again in order by the next one
+	defer func() {
+		for len(b.mc.readBuffer) > 0 {
+			<-b.mc.readBuffer
+			b.mc.release()
+		}
+	}()
+	b.connects++
+	if b.connects > 1 {
+		b.mc.telemetry.blazeReconnect.Add(ctx, 1)
+	}
+	b.mc.log().InfoContext(ctx, "blaze connected", "host", b.host, "user_id", b.uid, "connects", b.connects)
+
+	// the pumps of each connection signal on their own channels, so a late
+	// signal of a previous connection can't stop this one
+	mc := *b.mc
+	mc.readDone, mc.writeDone = make(chan bool, 1), make(chan bool, 1)
+
+	// the connection outlives ctx until the handlers are drained and the
+	// receipts flushed, then it's closed with a close frame
+	cctx, closeConn := context.WithCancel(context.WithoutCancel(ctx))
+	stopped := make(chan struct{})
+	defer func() {
+		closeConn()
+		select {
+		case <-stopped:
+		case <-time.After(writeWait):
+		}
+		conn.Close()
+		<-stopped
+	}()
+	go writePump(cctx, conn, &mc)
+	go func() {
+		defer close(stopped)
+		defer b.online.Store(false)
+		readPump(cctx, conn, &mc)
+	}()
 
 	if err = writeMessageAndWait(ctx, b.mc, "LIST_PENDING_MESSAGES", nil); err != nil {
 		return BlazeServerError(ctx, err)
 	}
+	b.online.Store(true)
+	if connected != nil {
+		connected()
+	}
+
+	// the receipts and the outbox stop with the connection, after the
+	// handlers are drained
+	if a := b.acknowledger; a != nil {
+		actx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			a.Run(actx)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+	}
+
+	if o := b.outbox; o != nil {
+		octx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			o.run(octx, b)
+		}()
+		defer func() {
+			cancel()
+			<-done
+		}()
+	}
+
+	// the handlers in progress when ctx is done are given the shutdown
+	// timeout to return, before their context is canceled
+	hctx, cancelHandlers := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelHandlers()
+	stopShutdown := context.AfterFunc(ctx, func() {
+		time.AfterFunc(b.shutdown, cancelHandlers)
+	})
+	defer stopShutdown()
+
+	var pool *blazePool
+	if b.workers > 1 {
+		pool = newBlazePool(hctx, b, listener)
+		defer func() {
+			// without a shutdown, the connection is lost and the handlers
+			// have nothing to drain for
+			if ctx.Err() == nil {
+				cancelHandlers()
+			}
+			pool.stop()
+		}()
+	}
 
 	for {
 		select {
-		case <-b.mc.readDone:
+		case <-ctx.Done():
+			b.mc.log().InfoContext(ctx, "blaze shutting down", "host", b.host, "user_id", b.uid)
+			return ctx.Err()
+		case <-mc.readDone:
+			b.mc.log().InfoContext(ctx, "blaze disconnected", "host", b.host, "user_id", b.uid)
 			return nil
 		case msg := <-b.mc.readBuffer:
+			b.mc.release()
+			b.mc.log().DebugContext(ctx, "blaze message",
+				"conversation_id", msg.ConversationId,
+				"message_id", msg.MessageId,
+				"category", msg.Category,
+				"source", msg.Source)
 			if msg.Source == "ACKNOWLEDGE_MESSAGE_RECEIPT" {
-				err = listener.OnAckReceipt(ctx, msg, b.uid)
-				if err != nil {
-					return err
+				if msg.Status == MessageStatusDelivered || msg.Status == MessageStatusRead {
+					b.status.set(msg.MessageId, msg.Status)
 				}
+				err = listener.OnAckReceipt(hctx, msg, b.uid)
 			} else {
-				err = listener.OnMessage(ctx, msg, b.uid)
-				if err != nil {
-					return err
-				}
-				if listener.SyncAck() {
-					params := map[string]any{"message_id": msg.MessageId, "status": "READ"}
-					if err = writeMessageAndWait(ctx, b.mc, "ACKNOWLEDGE_MESSAGE_RECEIPT", params); err != nil {
-						return BlazeServerError(ctx, err)
-					}
+				msg = b.client.decryptMessage(ctx, msg)
+				if pool != nil {
+					err = pool.dispatch(msg)
+				} else {
+					err = b.handleMessage(hctx, listener, msg)
 				}
 			}
+			if err != nil {
+				return err
+			}
+		case err := <-pool.failed():
+			return err
 		}
 	}
 }
 
-func (b *BlazeClient) SendMessage(ctx context.Context, conversationId, recipientId, messageId, category, content, representativeId string) error {
-	params := map[string]any{
-		"conversation_id":   conversationId,
-		"recipient_id":      recipientId,
-		"message_id":        messageId,
-		"category":          category,
-		"data_base64":       base64.RawURLEncoding.EncodeToString([]byte(content)),
-		"representative_id": representativeId,
-	}
-	if err := writeMessageAndWait(ctx, b.mc, createMessageAction, params); err != nil {
-		return BlazeServerError(ctx, err)
+// handleMessage acknowledges msg only after the listener has handled it, a
+// message not acknowledged is delivered again on the next connection.
+func (b *BlazeClient) handleMessage(ctx context.Context, listener BlazeListener, msg MessageView) error {
+	err := b.handleOnce(ctx, listener, msg)
+	if err != nil {
+		return err
+	}
+	if !listener.SyncAck() {
+		return nil
+	}
+	if b.acknowledger != nil {
+		b.acknowledger.Ack(msg.MessageId, AckStatusRead)
+	} else {
+		params := map[string]any{"message_id": msg.MessageId, "status": "READ"}
+		if err = writeMessageAndWait(ctx, b.mc, "ACKNOWLEDGE_MESSAGE_RECEIPT", params); err != nil {
+			return BlazeServerError(ctx, err)
+		}
 	}
 	return nil
 }
 
-func (b *BlazeClient) SendPlainText(ctx context.Context, msg MessageView, content string) error {
-	params := map[string]any{
-		"conversation_id": msg.ConversationId,
-		"recipient_id":    msg.UserId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryPlainText,
-		"data_base64":     base64.RawURLEncoding.EncodeToString([]byte(content)),
-	}
-	if err := writeMessageAndWait(ctx, b.mc, createMessageAction, params); err != nil {
-		return BlazeServerError(ctx, err)
+// handleOnce skips msg when the dedup store has seen it, and records it once
+// OnMessage returns nil.
+func (b *BlazeClient) handleOnce(ctx context.Context, listener BlazeListener, msg MessageView) error {
+	if b.dedup == nil {
+		return listener.OnMessage(ctx, msg, b.uid)
 	}
-	return nil
+	seen, err := b.dedup.Seen(ctx, msg.MessageId)
+	if err != nil || seen {
+		return err
+	}
+	err = listener.OnMessage(ctx, msg, b.uid)
+	if err != nil {
+		return err
+	}
+	return b.dedup.Record(ctx, msg.MessageId)
 }
 
-func (b *BlazeClient) SendRecallMessage(ctx context.Context, conversationId, recipientId, recallMessageId string) error {
-	c := RecallMessagePayload{
-		MessageId: recallMessageId,
-	}
-	a, _ := json.Marshal(c)
-	params := map[string]any{
-		"conversation_id": conversationId,
-		"recipient_id":    recipientId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryMessageRecall,
-		"data_base64":     base64.RawURLEncoding.EncodeToString(a),
-	}
-	if err := writeMessageAndWait(ctx, b.mc, createMessageAction, params); err != nil {
+// SetEncryption makes the senders send the PLAIN_* messages as ENCRYPTED_*
+// ones, the ENCRYPTED_* messages received are always decrypted before
+// OnMessage when they are encrypted for this session.
+func (b *BlazeClient) SetEncryption(enabled bool) {
+	b.client.SetEncryption(enabled)
+}
+
+// createMessage sends msg encrypted when needed, and sends it again with the
+// fresh sessions of the recipient when the checksum is rejected.
+func (b *BlazeClient) createMessage(ctx context.Context, msg *MessageRequest) error {
+	err := b.writeMessage(ctx, msg)
+	if errors.Is(err, ErrChecksumInvalid) {
+		b.client.InvalidateUserSessions(msg.RecipientId)
+		err = b.writeMessage(ctx, msg)
+	}
+	if err != nil {
 		return BlazeServerError(ctx, err)
 	}
+	b.status.set(msg.MessageId, MessageStatusSent)
 	return nil
 }
 
-func (b *BlazeClient) SendPost(ctx context.Context, msg MessageView, content string) error {
-	params := map[string]any{
-		"conversation_id": msg.ConversationId,
-		"recipient_id":    msg.UserId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryPlainPost,
-		"data_base64":     base64.RawURLEncoding.EncodeToString([]byte(content)),
-	}
-	if err := writeMessageAndWait(ctx, b.mc, createMessageAction, params); err != nil {
-		return BlazeServerError(ctx, err)
+func (b *BlazeClient) writeMessage(ctx context.Context, msg *MessageRequest) error {
+	msg, err := b.client.encryptMessage(ctx, msg)
+	if err != nil {
+		return err
 	}
-	return nil
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var params map[string]any
+	if err := json.Unmarshal(data, &params); err != nil {
+		return err
+	}
+	return writeMessageAndWait(ctx, b.mc, createMessageAction, params)
+}
+
+func (b *BlazeClient) SendMessage(ctx context.Context, conversationId, recipientId, messageId, category, content, representativeId string) error {
+	return b.createMessage(ctx, &MessageRequest{
+		ConversationId:   conversationId,
+		RecipientId:      recipientId,
+		MessageId:        messageId,
+		Category:         category,
+		DataBase64:       base64.RawURLEncoding.EncodeToString([]byte(content)),
+		RepresentativeId: representativeId,
+	})
+}
+
+func (b *BlazeClient) SendPlainText(ctx context.Context, msg MessageView, content string) error {
+	return b.sendBuilder(ctx, NewMessage(msg.ConversationId, msg.UserId).Text(content))
+}
+
+func (b *BlazeClient) SendRecallMessage(ctx context.Context, conversationId, recipientId, recallMessageId string) error {
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).Recall(recallMessageId))
+}
+
+func (b *BlazeClient) SendPost(ctx context.Context, msg MessageView, content string) error {
+	return b.sendBuilder(ctx, NewMessage(msg.ConversationId, msg.UserId).Post(content))
 }
 
 func (b *BlazeClient) SendContact(ctx context.Context, conversationId, recipientId, contactId string) error {
-	contactMap := map[string]string{"user_id": contactId}
-	contactData, _ := json.Marshal(contactMap)
-	params := map[string]any{
-		"conversation_id": conversationId,
-		"recipient_id":    recipientId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryPlainContact,
-		"data_base64":     base64.RawURLEncoding.EncodeToString(contactData),
-	}
-	if err := writeMessageAndWait(ctx, b.mc, createMessageAction, params); err != nil {
-		return BlazeServerError(ctx, err)
-	}
-	return nil
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).Contact(contactId))
 }
 
+// SendAppCard sends a card opening action, use SendAppCardView for the cards
+// with action buttons or an explicit shareable flag.
 func (b *BlazeClient) SendAppCard(ctx context.Context, conversationId, recipientId, title, description, action, iconUrl string) error {
-	data, err := json.Marshal(map[string]string{
+	// the legacy card leaves shareable to the server
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).Payload(MessageCategoryAppCard, map[string]string{
 		"title":       title,
 		"description": description,
 		"action":      action,
 		"icon_url":    iconUrl,
-	})
-	if err != nil {
-		return err
-	}
-	params := map[string]any{
-		"conversation_id": conversationId,
-		"recipient_id":    recipientId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryAppCard,
-		"data_base64":     base64.RawURLEncoding.EncodeToString(data),
-	}
-	err = writeMessageAndWait(ctx, b.mc, createMessageAction, params)
-	if err != nil {
-		return BlazeServerError(ctx, err)
-	}
-	return nil
+	}))
+}
+
+func (b *BlazeClient) SendAppCardView(ctx context.Context, conversationId, recipientId string, card *AppCardView) error {
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).AppCard(card))
 }
 
 func (b *BlazeClient) SendAppButton(ctx context.Context, conversationId, recipientId, label, action, color string) error {
-	btns, err := json.Marshal([]any{map[string]string{
-		"label":  label,
-		"action": action,
-		"color":  color,
-	}})
-	if err != nil {
-		return err
-	}
-	params := map[string]any{
-		"conversation_id": conversationId,
-		"recipient_id":    recipientId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryAppButtonGroup,
-		"data_base64":     base64.RawURLEncoding.EncodeToString(btns),
-	}
-	err = writeMessageAndWait(ctx, b.mc, createMessageAction, params)
-	if err != nil {
-		return BlazeServerError(ctx, err)
-	}
-	return nil
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).Buttons(&AppButtonView{
+		Label:  label,
+		Action: action,
+		Color:  color,
+	}))
 }
 
 func (b *BlazeClient) SendGroupAppButton(ctx context.Context, conversationId, recipientId string, buttons []*AppButtonView) error {
-	if len(buttons) > maximumButtons {
-		return fmt.Errorf("too many buttons, maximum is six")
-	}
-	btns, err := json.Marshal(buttons)
-	if err != nil {
-		return err
-	}
-	params := map[string]any{
-		"conversation_id": conversationId,
-		"recipient_id":    recipientId,
-		"message_id":      UuidNewV4().String(),
-		"category":        MessageCategoryAppButtonGroup,
-		"data_base64":     base64.RawURLEncoding.EncodeToString(btns),
-	}
-	err = writeMessageAndWait(ctx, b.mc, createMessageAction, params)
-	if err != nil {
-		return BlazeServerError(ctx, err)
-	}
-	return nil
+	return b.sendBuilder(ctx, NewMessage(conversationId, recipientId).Buttons(buttons...))
 }
 
-func (b *BlazeClient) connectMixinBlaze() (*websocket.Conn, error) {
+func (b *BlazeClient) connectMixinBlaze(ctx context.Context) (BlazeTransport, error) {
 	user := &SafeUser{
 		UserId:            b.uid,
 		SessionId:         b.sid,
@@ -369,80 +554,108 @@ func (b *BlazeClient) connectMixinBlaze() (*websocket.Conn, error) {
 	}
 	header := make(http.Header)
 	header.Add("Authorization", "Bearer "+token)
-	u := url.URL{Scheme: "wss", Host: blazeUri, Path: "/"}
-	conn, _, err := b.dailer.Dial(u.String(), header)
+	u := url.URL{Scheme: "wss", Host: b.host, Path: "/"}
+	conn, err := b.dial(ctx, u.String(), header)
 	if err != nil {
 		if strings.Contains(err.Error(), "timeout") {
-			blazeUri = DefaultBlazeHost
+			b.host = DefaultBlazeHost
 		}
 		return nil, err
 	}
 	return conn, nil
 }
 
-func readPump(ctx context.Context, conn *websocket.Conn, mc *messageContext) error {
+// readPump reads until the transport is closed, ctx is not passed to the
+// transport so that a shutdown can wait for the close frame of the server.
+func readPump(ctx context.Context, conn BlazeTransport, mc *messageContext) error {
 	defer func() {
 		conn.Close()
 		mc.writeDone <- true
 		mc.readDone <- true
 	}()
 
-	conn.SetReadDeadline(time.Now().Add(pongWait))
-	conn.SetPongHandler(func(string) error {
-		err := conn.SetReadDeadline(time.Now().Add(pongWait))
-		if err != nil {
-			return BlazeServerError(ctx, err)
-		}
-		return nil
-	})
-
 	for {
-		err := conn.SetReadDeadline(time.Now().Add(pongWait))
-		if err != nil {
-			return BlazeServerError(ctx, err)
-		}
-		messageType, wsReader, err := conn.NextReader()
+		data, err := conn.ReadMessage(context.WithoutCancel(ctx))
 		if err != nil {
 			return BlazeServerError(ctx, err)
 		}
-		if messageType != websocket.BinaryMessage {
-			return BlazeServerError(ctx, fmt.Errorf("invalid message type %d", messageType))
-		}
-		err = parseMessage(ctx, mc, wsReader)
+		err = parseMessage(ctx, mc, bytes.NewReader(data))
 		if err != nil {
 			return BlazeServerError(ctx, err)
 		}
 	}
 }
 
-func writePump(ctx context.Context, conn *websocket.Conn, mc *messageContext) error {
+func writePump(ctx context.Context, conn BlazeTransport, mc *messageContext) error {
 	ticker := time.NewTicker(pingPeriod)
-	defer func() {
-		ticker.Stop()
-		conn.Close()
-	}()
+	defer ticker.Stop()
 	for {
 		select {
 		case data := <-mc.writeBuffer:
-			err := writeGzipToConn(conn, data)
+			err := writeGzipToConn(ctx, conn, data)
 			if err != nil {
+				conn.Close()
 				return BlazeServerError(ctx, err)
 			}
 		case <-mc.writeDone:
 			return nil
+		case <-ctx.Done():
+			// the transport is closed by readPump once the server answers
+			// the close frame, or by the loop after writeWait
+			return conn.Shutdown(context.WithoutCancel(ctx))
 		case <-ticker.C:
-			conn.SetWriteDeadline(time.Now().Add(writeWait))
-			err := conn.WriteMessage(websocket.PingMessage, nil)
+			err := conn.Ping(context.WithoutCancel(ctx))
 			if err != nil {
+				conn.Close()
 				return BlazeServerError(ctx, err)
 			}
 		}
 	}
 }
 
-func writeMessageAndWait(ctx context.Context, mc *messageContext, action string, params map[string]any) error {
+// writeMessageAndWait sends the action and waits for its reply, an error
+// reply or a timeout is retried with the same id and backoff up to
+// mc.retry.MaxAttempts times. A forbidden action is dropped without an error.
+func writeMessageAndWait(ctx context.Context, mc *messageContext, action string, params map[string]any) (err error) {
+	ctx, span := mc.telemetry.start(ctx, "blaze "+action, trace.SpanKindClient,
+		attribute.String("mixin.blaze.action", action))
+	start, code := time.Now(), 0
+	defer func() {
+		mc.telemetry.recordBlaze(ctx, action, start, code, err)
+		endSpan(span, err)
+	}()
+
+	id := UuidNewV4().String()
+	span.SetAttributes(attribute.String("mixin.blaze.id", id))
+	for attempt := 1; ; attempt++ {
+		span.SetAttributes(attribute.Int("mixin.attempts", attempt))
+		t, err := writeMessageOnce(ctx, mc, id, action, params)
+		if err != nil && !errors.Is(err, errBlazeActionTimeout) {
+			return err
+		}
+		if err == nil {
+			if t.Error == nil || t.Error.Code == ErrorCodeForbidden {
+				// an action succeeding after a retry is not counted as failed
+				code = 0
+				return nil
+			}
+			code = t.Error.Code
+			span.SetAttributes(attribute.Int("mixin.error.code", code))
+			err = *t.Error
+		}
+		if code == ErrorCodeChecksumInvalid || mc.retry == nil || attempt >= mc.retry.MaxAttempts {
+			return err
+		}
+		delay := mc.retry.backoff(attempt)
+		mc.log().WarnContext(ctx, "blaze action error, retrying", "action", action, "id", id, "error", err, "attempt", attempt, "delay", delay)
+		if err := sleepContext(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+func writeMessageOnce(ctx context.Context, mc *messageContext, id, action string, params map[string]any) (*BlazeMessage, error) {
 	var resp = make(chan BlazeMessage, 1)
-	var id = UuidNewV4().String()
 	mc.transactions.set(id, func(t BlazeMessage) error {
 		select {
 		case resp <- t:
@@ -451,44 +664,41 @@ func writeMessageAndWait(ctx context.Context, mc *messageContext, action string,
 		}
 		return nil
 	})
+	defer mc.transactions.retrieve(id)
 	blazeMessage, err := json.Marshal(BlazeMessage{Id: id, Action: action, Params: params})
 	if err != nil {
-		return err
+		return nil, err
 	}
 	select {
-	case <-time.After(keepAlivePeriod):
-		return fmt.Errorf("timeout to write %s %v", action, params)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(mc.timeout):
+		return nil, fmt.Errorf("%w to write %s %s", errBlazeActionTimeout, action, id)
 	case mc.writeBuffer <- blazeMessage:
 	}
 	select {
-	case <-time.After(keepAlivePeriod):
-		return fmt.Errorf("timeout to wait %s %v", action, params)
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(mc.timeout):
+		return nil, fmt.Errorf("%w to wait %s %s", errBlazeActionTimeout, action, id)
 	case t := <-resp:
-		if t.Error != nil && t.Error.Code != 403 {
-			return writeMessageAndWait(ctx, mc, action, params)
-		}
+		return &t, nil
 	}
-	return nil
 }
 
-func writeGzipToConn(conn *websocket.Conn, msg []byte) error {
-	conn.SetWriteDeadline(time.Now().Add(writeWait))
-	wsWriter, err := conn.NextWriter(websocket.BinaryMessage)
-	if err != nil {
-		return err
-	}
-	gzWriter, err := gzip.NewWriterLevel(wsWriter, 3)
+func writeGzipToConn(ctx context.Context, conn BlazeTransport, msg []byte) error {
+	var buf bytes.Buffer
+	gzWriter, err := gzip.NewWriterLevel(&buf, 3)
 	if err != nil {
 		return err
 	}
 	if _, err := gzWriter.Write(msg); err != nil {
 		return err
 	}
-
 	if err := gzWriter.Close(); err != nil {
 		return err
 	}
-	return wsWriter.Close()
+	return conn.WriteMessage(context.WithoutCancel(ctx), buf.Bytes())
 }
 
 func parseMessage(ctx context.Context, mc *messageContext, wsReader io.Reader) error {
@@ -514,10 +724,15 @@ func parseMessage(ctx context.Context, mc *messageContext, wsReader io.Reader) e
 	if err = json.Unmarshal(message.Data, &msg); err != nil {
 		return err
 	}
+	if mc.budget != nil {
+		return mc.enqueue(ctx, msg)
+	}
 	timer := time.NewTimer(keepAlivePeriod)
 	defer timer.Stop()
 
 	select {
+	case <-ctx.Done():
+		return ctx.Err()
 	case <-timer.C:
 		timer.Reset(keepAlivePeriod)
 		return fmt.Errorf("timeout to handle %s %s", msg.Category, msg.MessageId)
@@ -526,6 +741,29 @@ func parseMessage(ctx context.Context, mc *messageContext, wsReader io.Reader) e
 	return nil
 }
 
+// enqueue waits for a slot of the budget and then for the handler, without a
+// timeout, so a slow bot stops reading its connection instead of dropping it.
+func (mc *messageContext) enqueue(ctx context.Context, msg MessageView) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case mc.budget <- struct{}{}:
+	}
+	select {
+	case <-ctx.Done():
+		<-mc.budget
+		return ctx.Err()
+	case mc.readBuffer <- msg:
+		return nil
+	}
+}
+
+func (mc *messageContext) release() {
+	if mc.budget != nil {
+		<-mc.budget
+	}
+}
+
 type tmap struct {
 	mutex sync.Mutex
 	m     map[string]mixinTransaction
@@ -551,3 +789,16 @@ func (m *tmap) set(key string, t mixinTransaction) {
 	defer m.mutex.Unlock()
 	m.m[key] = t
 }
+
+// clear answers all transactions with a blaze server error, the writers
+// send them again on the next connection.
+func (m *tmap) clear() {
+	m.mutex.Lock()
+	pending := m.m
+	m.m = make(map[string]mixinTransaction)
+	m.mutex.Unlock()
+	for id, t := range pending {
+		e := ErrBlazeServer
+		t(BlazeMessage{Id: id, Error: &e})
+	}
+}
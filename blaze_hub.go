@@ -0,0 +1,199 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultHubBufferSize = 256
+	defaultHubBudget     = 4096
+)
+
+// BlazeHub runs the Blaze connections of many bots in one process. The bots
+// share the reconnect policy of the hub, and all connections, including the
+// reconnections, are paced by one token bucket, so that a server outage
+// doesn't make all bots dial at once. Each bot buffers 256 messages each way
+// by default, instead of the 102400 of NewBlazeClient, and all bots together
+// hold at most 4096 messages read but not yet handled. A bot waiting for its
+// handler or for the budget stops reading its connection until it can go on.
+type BlazeHub struct {
+	client     *Client
+	dial       BlazeDialer
+	bufferSize int
+	budget     chan struct{}
+	supervisor BlazeSupervisor
+
+	// OnStateChange is called on every state change of a bot.
+	OnStateChange func(userId string, state BlazeState, err error)
+
+	mutex  sync.Mutex
+	gate   *tokenBucket
+	bots   map[string]*hubBot
+	closed bool
+}
+
+type hubBot struct {
+	client *BlazeClient
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewBlazeHub(s *BlazeSupervisor) *BlazeHub {
+	return defaultClient.NewBlazeHub(s)
+}
+
+// NewBlazeHub returns a hub dialing the Blaze host of c, the bots reconnect
+// with the backoff and retry budget of s, and a bot exhausting the budget is
+// removed from the hub. The connections are paced to 10 per second.
+func (c *Client) NewBlazeHub(s *BlazeSupervisor) *BlazeHub {
+	if s == nil {
+		s = NewBlazeSupervisor(0)
+	}
+	h := &BlazeHub{
+		client:     c,
+		bufferSize: defaultHubBufferSize,
+		budget:     make(chan struct{}, defaultHubBudget),
+		supervisor: *s,
+		bots:       make(map[string]*hubBot),
+	}
+	h.SetConnectRate(10, 10)
+	return h
+}
+
+// SetConnectRate allows rate connections per second for all bots, with
+// bursts of burst connections.
+func (h *BlazeHub) SetConnectRate(rate float64, burst int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.gate = &tokenBucket{limit: rateLimit{rate: rate, burst: burst}, tokens: float64(burst), last: time.Now()}
+}
+
+// SetBufferSize sets the buffer size of the bots created after it.
+func (h *BlazeHub) SetBufferSize(size int) {
+	h.bufferSize = size
+}
+
+// SetBudget sets how many messages the bots created after it hold at most
+// together before they are handled.
+func (h *BlazeHub) SetBudget(size int) {
+	h.budget = make(chan struct{}, size)
+}
+
+func (h *BlazeHub) SetupDailer(dailer *websocket.Dialer) {
+	h.dial = GorillaBlazeDialer(dailer)
+}
+
+// SetTransport makes the bots created after it connect with dial.
+func (h *BlazeHub) SetTransport(dial BlazeDialer) {
+	h.dial = dial
+}
+
+// NewBlazeClient returns a client of user with the buffers and transport of
+// the hub, it can be configured before it's added to the hub. The client
+// acknowledges the messages in batches, a receipt waiting for its reply in
+// Loop would stop reading once the small buffer is full. For the same reason,
+// the handlers should send messages with the outbox or the worker pool.
+func (h *BlazeHub) NewBlazeClient(user *SafeUser) *BlazeClient {
+	b := h.client.WithSafeUser(user).newBlazeClient(h.bufferSize)
+	b.mc.budget = h.budget
+	if h.dial != nil {
+		b.SetTransport(h.dial)
+	}
+	b.NewAcknowledger(h.bufferSize, time.Second)
+	return b
+}
+
+// Add starts the connection of b, its messages are handled by listener.
+func (h *BlazeHub) Add(b *BlazeClient, listener BlazeListener) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if h.closed {
+		return fmt.Errorf("blaze hub closed")
+	}
+	if h.bots[b.uid] != nil {
+		return fmt.Errorf("blaze hub bot %s already added", b.uid)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	bot := &hubBot{client: b, cancel: cancel, done: make(chan struct{})}
+	h.bots[b.uid] = bot
+
+	s := h.supervisor
+	s.gate = h.wait
+	s.OnStateChange = func(state BlazeState, err error) {
+		if h.OnStateChange != nil {
+			h.OnStateChange(b.uid, state, err)
+		}
+	}
+	go func() {
+		defer close(bot.done)
+		err := b.Supervise(ctx, listener, &s)
+		if ctx.Err() == nil {
+			b.mc.log().WarnContext(ctx, "blaze hub bot stopped", "user_id", b.uid, "error", err)
+		}
+		h.mutex.Lock()
+		defer h.mutex.Unlock()
+		if h.bots[b.uid] == bot {
+			delete(h.bots, b.uid)
+		}
+	}()
+	return nil
+}
+
+// Remove stops the connection of the bot, and waits for its handlers.
+func (h *BlazeHub) Remove(userId string) error {
+	h.mutex.Lock()
+	bot := h.bots[userId]
+	delete(h.bots, userId)
+	h.mutex.Unlock()
+	if bot == nil {
+		return fmt.Errorf("blaze hub bot %s not found", userId)
+	}
+	bot.cancel()
+	<-bot.done
+	return nil
+}
+
+// Bots returns the user ids of the running bots.
+func (h *BlazeHub) Bots() []string {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return slices.Sorted(maps.Keys(h.bots))
+}
+
+func (h *BlazeHub) Client(userId string) *BlazeClient {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if bot := h.bots[userId]; bot != nil {
+		return bot.client
+	}
+	return nil
+}
+
+// Close stops all bots and waits for them, no bot can be added after it.
+func (h *BlazeHub) Close() {
+	h.mutex.Lock()
+	h.closed = true
+	bots := slices.Collect(maps.Values(h.bots))
+	clear(h.bots)
+	h.mutex.Unlock()
+	for _, bot := range bots {
+		bot.cancel()
+	}
+	for _, bot := range bots {
+		<-bot.done
+	}
+}
+
+func (h *BlazeHub) wait(ctx context.Context) error {
+	h.mutex.Lock()
+	delay := h.gate.reserve(time.Now())
+	h.mutex.Unlock()
+	return sleepContext(ctx, delay)
+}
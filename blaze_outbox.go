@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"sync"
+)
+
+const (
+	MessageStatusSent      = "SENT"
+	MessageStatusDelivered = "DELIVERED"
+	MessageStatusRead      = "READ"
+	MessageStatusFailed    = "FAILED"
+
+	messageStatusLimit = 10000
+)
+
+var messageStatusRank = map[string]int{
+	MessageStatusSent:      1,
+	MessageStatusDelivered: 2,
+	MessageStatusRead:      3,
+	MessageStatusFailed:    4,
+}
+
+var ErrOutboxFull = errors.New("blaze outbox is full")
+
+// outbox queues messages to send over Blaze, a message keeps its message id
+// on every attempt, so that a retry never duplicates it. A message
+// failing policy.MaxAttempts times is given to the dead letter callback.
+type outbox struct {
+	policy     *RetryPolicy
+	deadLetter func(msg *MessageRequest, err error)
+	queue      chan *outboxItem
+
+	mutex sync.Mutex
+	stash []*outboxItem
+}
+
+type outboxItem struct {
+	msg      *MessageRequest
+	attempts int
+}
+
+// SetOutbox enables Enqueue with a queue of size messages, the queue is sent
+// while Loop is connected. A nil policy retries 5 times.
+func (b *BlazeClient) SetOutbox(size int, policy *RetryPolicy, deadLetter func(msg *MessageRequest, err error)) {
+	if policy == nil {
+		policy = NewRetryPolicy(5)
+	}
+	b.outbox = &outbox{
+		policy:     policy,
+		deadLetter: deadLetter,
+		queue:      make(chan *outboxItem, size),
+	}
+}
+
+// Enqueue queues msg without blocking, a random message id is assigned when
+// empty. It returns ErrOutboxFull when the queue is full.
+func (b *BlazeClient) Enqueue(msg *MessageRequest) error {
+	if b.outbox == nil {
+		return errors.New("blaze outbox is not enabled")
+	}
+	if msg.MessageId == "" {
+		msg.MessageId = UuidNewV4().String()
+	}
+	select {
+	case b.outbox.queue <- &outboxItem{msg: msg}:
+		return nil
+	default:
+		return ErrOutboxFull
+	}
+}
+
+// run sends the queued messages until ctx is done, a message interrupted by
+// the end of the connection is stashed and sent first by the next run.
+func (o *outbox) run(ctx context.Context, b *BlazeClient) {
+	for {
+		item := o.unstash()
+		if item == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case item = <-o.queue:
+			}
+		}
+		if !o.send(ctx, b, item) {
+			o.mutex.Lock()
+			o.stash = append(o.stash, item)
+			o.mutex.Unlock()
+			return
+		}
+	}
+}
+
+// send returns false when ctx is done before item is sent or dead lettered.
+func (o *outbox) send(ctx context.Context, b *BlazeClient, item *outboxItem) bool {
+	for {
+		item.attempts++
+		err := b.createMessage(ctx, item.msg)
+		if err == nil {
+			return true
+		}
+		if ctx.Err() != nil {
+			item.attempts--
+			return false
+		}
+		if item.attempts >= o.policy.MaxAttempts {
+			b.mc.log().WarnContext(ctx, "blaze outbox dead letter", "message_id", item.msg.MessageId, "attempts", item.attempts, "error", err)
+			b.status.set(item.msg.MessageId, MessageStatusFailed)
+			if o.deadLetter != nil {
+				o.deadLetter(item.msg, err)
+			}
+			return true
+		}
+		if sleepContext(ctx, o.policy.backoff(item.attempts)) != nil {
+			return false
+		}
+	}
+}
+
+func (o *outbox) unstash() *outboxItem {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+	if len(o.stash) == 0 {
+		return nil
+	}
+	item := o.stash[0]
+	o.stash = o.stash[1:]
+	return item
+}
+
+// MessageStatus returns the status of a message sent by this client, SENT
+// once accepted by the server, then DELIVERED and READ from the receipts, or
+// FAILED when dead lettered. It returns an empty status for unknown messages,
+// only the last 10000 messages are tracked.
+func (b *BlazeClient) MessageStatus(messageId string) string {
+	return b.status.get(messageId)
+}
+
+type messageStatus struct {
+	mutex sync.Mutex
+	order *list.List
+	ids   map[string]*list.Element
+}
+
+type messageStatusEntry struct {
+	id     string
+	status string
+}
+
+func newMessageStatus() *messageStatus {
+	return &messageStatus{order: list.New(), ids: make(map[string]*list.Element)}
+}
+
+func (ms *messageStatus) get(id string) string {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if e, ok := ms.ids[id]; ok {
+		return e.Value.(*messageStatusEntry).status
+	}
+	return ""
+}
+
+// set records the status of a message, it never goes back from READ to
+// DELIVERED or SENT, since a receipt may arrive before the reply of the
+// CREATE_MESSAGE.
+func (ms *messageStatus) set(id, status string) {
+	ms.mutex.Lock()
+	defer ms.mutex.Unlock()
+	if e, ok := ms.ids[id]; ok {
+		entry := e.Value.(*messageStatusEntry)
+		if messageStatusRank[status] > messageStatusRank[entry.status] {
+			entry.status = status
+		}
+		ms.order.MoveToBack(e)
+		return
+	}
+	ms.ids[id] = ms.order.PushBack(&messageStatusEntry{id: id, status: status})
+	for ms.order.Len() > messageStatusLimit {
+		e := ms.order.Front()
+		ms.order.Remove(e)
+		delete(ms.ids, e.Value.(*messageStatusEntry).id)
+	}
+}
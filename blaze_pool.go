@@ -0,0 +1,108 @@
+package bot
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+)
+
+const defaultBlazeQueueSize = 64
+
+// SetConcurrency makes Loop handle the messages with workers goroutines, the
+// messages of a conversation are always handled by the same worker in order,
+// while different conversations are handled in parallel. Each worker queues
+// at most queueSize messages, and the reading of new messages blocks when the
+// queue is full. A workers count less than 2 handles messages serially.
+func (b *BlazeClient) SetConcurrency(workers, queueSize int) {
+	if queueSize < 1 {
+		queueSize = defaultBlazeQueueSize
+	}
+	b.workers, b.queueSize = workers, queueSize
+}
+
+// blazePool lives for one connection, the first handler error stops all
+// workers and is returned by the loop, so that the messages not acknowledged
+// are delivered again after reconnecting.
+type blazePool struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	queues []chan MessageView
+	errors chan error
+	wg     sync.WaitGroup
+}
+
+// newBlazePool handles the messages with hctx, which is not canceled when the
+// pool stops, so that stop can wait for the handlers to return.
+func newBlazePool(hctx context.Context, b *BlazeClient, listener BlazeListener) *blazePool {
+	ctx, cancel := context.WithCancel(hctx)
+	p := &blazePool{
+		ctx:    ctx,
+		cancel: cancel,
+		queues: make([]chan MessageView, b.workers),
+		errors: make(chan error, 1),
+	}
+	for i := range p.queues {
+		p.queues[i] = make(chan MessageView, b.queueSize)
+		p.wg.Add(1)
+		go p.work(p.queues[i], func(msg MessageView) error {
+			return b.handleMessage(hctx, listener, msg)
+		})
+	}
+	return p
+}
+
+func (p *blazePool) work(queue chan MessageView, handle func(MessageView) error) {
+	defer p.wg.Done()
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case msg := <-queue:
+			if p.ctx.Err() != nil {
+				return
+			}
+			if err := handle(msg); err != nil {
+				select {
+				case p.errors <- err:
+				default:
+				}
+				p.cancel()
+				return
+			}
+		}
+	}
+}
+
+func (p *blazePool) dispatch(msg MessageView) error {
+	h := fnv.New32a()
+	h.Write([]byte(msg.ConversationId))
+	queue := p.queues[h.Sum32()%uint32(len(p.queues))]
+	select {
+	case queue <- msg:
+		return nil
+	case err := <-p.errors:
+		return err
+	case <-p.ctx.Done():
+		select {
+		case err := <-p.errors:
+			return err
+		default:
+			return p.ctx.Err()
+		}
+	}
+}
+
+// failed is nil for a nil pool, so the loop can always select on it.
+func (p *blazePool) failed() <-chan error {
+	if p == nil {
+		return nil
+	}
+	return p.errors
+}
+
+// stop waits for the handlers in progress, so they don't overlap with the
+// handlers of the next connection.
+func (p *blazePool) stop() {
+	p.cancel()
+	p.wg.Wait()
+}
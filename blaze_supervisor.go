@@ -0,0 +1,93 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type BlazeState string
+
+const (
+	BlazeStateConnecting BlazeState = "connecting"
+	BlazeStateConnected  BlazeState = "connected"
+	BlazeStateDegraded   BlazeState = "degraded"
+	BlazeStateClosed     BlazeState = "closed"
+)
+
+var ErrBlazeRetryBudget = errors.New("blaze reconnect budget exhausted")
+
+// BlazeSupervisor keeps a Blaze client connected, a lost connection is
+// dialed again after an exponential backoff with jitter. MaxRetries limits
+// the consecutive failed connections, the count is reset once a connection
+// has listed its pending messages, and zero means no limit.
+type BlazeSupervisor struct {
+	MaxRetries int
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnStateChange is called on every state change, err is the reason of
+	// the degraded and closed states.
+	OnStateChange func(state BlazeState, err error)
+
+	// gate is waited before each connection, the BlazeHub paces the
+	// connections of all bots with it.
+	gate func(ctx context.Context) error
+}
+
+func NewBlazeSupervisor(maxRetries int) *BlazeSupervisor {
+	return &BlazeSupervisor{
+		MaxRetries: maxRetries,
+		MinBackoff: time.Second,
+		MaxBackoff: time.Minute,
+	}
+}
+
+func (s *BlazeSupervisor) notify(state BlazeState, err error) {
+	if s.OnStateChange != nil {
+		s.OnStateChange(state, err)
+	}
+}
+
+// Supervise runs Loop until ctx is done or the retry budget is exhausted.
+// Each new connection lists the pending messages again, so messages not
+// acknowledged before a disconnect are delivered to the listener again.
+// A listener error also drops the connection, to retry the message later.
+func (b *BlazeClient) Supervise(ctx context.Context, listener BlazeListener, s *BlazeSupervisor) error {
+	if s == nil {
+		s = NewBlazeSupervisor(0)
+	}
+	backoff := &RetryPolicy{MinBackoff: s.MinBackoff, MaxBackoff: s.MaxBackoff}
+	for failures := 0; ; {
+		if err := ctx.Err(); err != nil {
+			s.notify(BlazeStateClosed, err)
+			return err
+		}
+		s.notify(BlazeStateConnecting, nil)
+		if s.gate != nil {
+			if err := s.gate(ctx); err != nil {
+				s.notify(BlazeStateClosed, err)
+				return err
+			}
+		}
+		err := b.loop(ctx, listener, func() {
+			failures = 0
+			s.notify(BlazeStateConnected, nil)
+		})
+		if err == nil {
+			err = ErrBlazeServer
+		}
+		failures++
+		if s.MaxRetries > 0 && failures > s.MaxRetries {
+			err = errors.Join(ErrBlazeRetryBudget, err)
+			s.notify(BlazeStateClosed, err)
+			return err
+		}
+		s.notify(BlazeStateDegraded, err)
+		delay := backoff.backoff(failures)
+		b.mc.log().WarnContext(ctx, "blaze reconnecting", "user_id", b.uid, "failures", failures, "delay", delay, "error", err)
+		if err := sleepContext(ctx, delay); err != nil {
+			s.notify(BlazeStateClosed, err)
+			return err
+		}
+	}
+}
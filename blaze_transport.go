@@ -0,0 +1,185 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const blazeSubprotocol = "Mixin-Blaze-1"
+
+var ErrBlazeTransportClosed = errors.New("blaze transport closed")
+
+// BlazeTransport is a connection to the Blaze server carrying the gzipped
+// binary frames of the Blaze messages. ReadMessage is called by one goroutine
+// while WriteMessage, Ping and Shutdown are called by another one, Close may
+// be called by any goroutine.
+type BlazeTransport interface {
+	// ReadMessage returns the next binary frame, and fails when no frame or
+	// pong has been received for a while.
+	ReadMessage(ctx context.Context) ([]byte, error)
+	WriteMessage(ctx context.Context, data []byte) error
+	Ping(ctx context.Context) error
+	// Shutdown sends a normal close frame, ReadMessage fails once the peer
+	// answers it.
+	Shutdown(ctx context.Context) error
+	Close() error
+}
+
+// BlazeDialer connects to the Blaze server at url, with the authorization
+// in header.
+type BlazeDialer func(ctx context.Context, url string, header http.Header) (BlazeTransport, error)
+
+// SetTransport makes the client connect with dial, e.g. CoderBlazeDialer or
+// an in-memory transport made by NewBlazePipe.
+func (b *BlazeClient) SetTransport(dial BlazeDialer) {
+	b.dial = dial
+}
+
+// GorillaBlazeDialer dials with a copy of dailer, a nil dailer uses the
+// defaults. Set its Proxy to connect through an HTTP CONNECT proxy.
+func GorillaBlazeDialer(dailer *websocket.Dialer) BlazeDialer {
+	d := websocket.Dialer{}
+	if dailer != nil {
+		d = *dailer
+	}
+	d.Subprotocols = []string{blazeSubprotocol}
+	if d.HandshakeTimeout == 0 {
+		d.HandshakeTimeout = time.Second * 5
+	}
+	return func(ctx context.Context, url string, header http.Header) (BlazeTransport, error) {
+		conn, _, err := d.DialContext(ctx, url, header)
+		if err != nil {
+			return nil, err
+		}
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(pongWait))
+		})
+		return &gorillaTransport{conn: conn}, nil
+	}
+}
+
+type gorillaTransport struct {
+	conn *websocket.Conn
+}
+
+func (t *gorillaTransport) ReadMessage(ctx context.Context) ([]byte, error) {
+	err := t.conn.SetReadDeadline(time.Now().Add(pongWait))
+	if err != nil {
+		return nil, err
+	}
+	messageType, data, err := t.conn.ReadMessage()
+	if err != nil {
+		return nil, err
+	}
+	if messageType != websocket.BinaryMessage {
+		return nil, fmt.Errorf("invalid message type %d", messageType)
+	}
+	return data, nil
+}
+
+func (t *gorillaTransport) WriteMessage(ctx context.Context, data []byte) error {
+	t.conn.SetWriteDeadline(writeDeadline(ctx))
+	return t.conn.WriteMessage(websocket.BinaryMessage, data)
+}
+
+func (t *gorillaTransport) Ping(ctx context.Context) error {
+	return t.conn.WriteControl(websocket.PingMessage, nil, writeDeadline(ctx))
+}
+
+func (t *gorillaTransport) Shutdown(ctx context.Context) error {
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	return t.conn.WriteControl(websocket.CloseMessage, msg, writeDeadline(ctx))
+}
+
+func (t *gorillaTransport) Close() error {
+	return t.conn.Close()
+}
+
+func writeDeadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(writeWait)
+}
+
+// NewBlazePipe returns the two ends of an in-memory transport, e.g. to serve
+// a BlazeClient from a test without a network. Pings are ignored, and a
+// Shutdown of one end is read as an error by the other end, which should
+// Close in reply.
+func NewBlazePipe() (BlazeTransport, BlazeTransport) {
+	a, b := newPipeTransport(), newPipeTransport()
+	a.peer, b.peer = b, a
+	return a, b
+}
+
+type pipeTransport struct {
+	peer    *pipeTransport
+	frames  chan []byte
+	closing chan struct{}
+	closed  chan struct{}
+
+	shutdownOnce sync.Once
+	closeOnce    sync.Once
+}
+
+func newPipeTransport() *pipeTransport {
+	return &pipeTransport{
+		frames:  make(chan []byte, 64),
+		closing: make(chan struct{}),
+		closed:  make(chan struct{}),
+	}
+}
+
+// ReadMessage returns the frames written before the peer shut down or closed,
+// then fails.
+func (t *pipeTransport) ReadMessage(ctx context.Context) ([]byte, error) {
+	select {
+	case data := <-t.frames:
+		return data, nil
+	case <-t.closed:
+		return nil, ErrBlazeTransportClosed
+	case <-t.peer.closing:
+	case <-t.peer.closed:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case data := <-t.frames:
+		return data, nil
+	default:
+		return nil, ErrBlazeTransportClosed
+	}
+}
+
+func (t *pipeTransport) WriteMessage(ctx context.Context, data []byte) error {
+	select {
+	case t.peer.frames <- data:
+		return nil
+	case <-t.closed:
+		return ErrBlazeTransportClosed
+	case <-t.peer.closed:
+		return ErrBlazeTransportClosed
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *pipeTransport) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (t *pipeTransport) Shutdown(ctx context.Context) error {
+	t.shutdownOnce.Do(func() { close(t.closing) })
+	return nil
+}
+
+func (t *pipeTransport) Close() error {
+	t.closeOnce.Do(func() { close(t.closed) })
+	return nil
+}
@@ -0,0 +1,110 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// CoderBlazeDialer dials with github.com/coder/websocket and a copy of opts,
+// a nil opts uses the defaults. Set the Proxy of the transport of
+// opts.HTTPClient to connect through an HTTP CONNECT proxy.
+func CoderBlazeDialer(opts *websocket.DialOptions) BlazeDialer {
+	return coderDialer(opts, blazeSubprotocol)
+}
+
+func coderDialer(opts *websocket.DialOptions, subprotocol string) BlazeDialer {
+	o := websocket.DialOptions{}
+	if opts != nil {
+		o = *opts
+	}
+	o.Subprotocols = []string{subprotocol}
+	return func(ctx context.Context, url string, header http.Header) (BlazeTransport, error) {
+		o := o
+		o.HTTPHeader = header.Clone()
+		if o.HTTPHeader == nil {
+			o.HTTPHeader = make(http.Header)
+		}
+		if opts != nil {
+			for k, v := range opts.HTTPHeader {
+				if _, ok := o.HTTPHeader[k]; !ok {
+					o.HTTPHeader[k] = v
+				}
+			}
+		}
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		conn, _, err := websocket.Dial(ctx, url, &o)
+		if err != nil {
+			return nil, err
+		}
+		conn.SetReadLimit(-1)
+		return &coderTransport{conn: conn}, nil
+	}
+}
+
+type coderTransport struct {
+	conn    *websocket.Conn
+	pinging atomic.Bool
+}
+
+// ReadMessage doesn't time out by itself, a connection without pongs is
+// closed by Ping.
+func (t *coderTransport) ReadMessage(ctx context.Context) ([]byte, error) {
+	messageType, data, err := t.conn.Read(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if messageType != websocket.MessageBinary {
+		return nil, fmt.Errorf("invalid message type %d", messageType)
+	}
+	return data, nil
+}
+
+func (t *coderTransport) WriteMessage(ctx context.Context, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, writeWait)
+	defer cancel()
+	return t.conn.Write(ctx, websocket.MessageBinary, data)
+}
+
+// Ping waits for the pong in the background, so that it doesn't block the
+// writes, and closes the connection when the pong is late. A ping is skipped
+// while the previous one waits for its pong.
+func (t *coderTransport) Ping(ctx context.Context) error {
+	if !t.pinging.CompareAndSwap(false, true) {
+		return nil
+	}
+	go func() {
+		defer t.pinging.Store(false)
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), pongWait)
+		defer cancel()
+		if err := t.conn.Ping(ctx); err != nil {
+			t.conn.CloseNow()
+		}
+	}()
+	return nil
+}
+
+// Shutdown returns once the peer answers the close frame, or when ctx is
+// done. coder/websocket can't interrupt a close handshake, neither with
+// CloseNow, so it goes on in the background for up to 5 seconds.
+func (t *coderTransport) Shutdown(ctx context.Context) error {
+	closed := make(chan error, 1)
+	go func() {
+		closed <- t.conn.Close(websocket.StatusNormalClosure, "")
+	}()
+	select {
+	case err := <-closed:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *coderTransport) Close() error {
+	return t.conn.CloseNow()
+}
@@ -0,0 +1,130 @@
+package bot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type pipeListener struct {
+	messages chan MessageView
+}
+
+func (l *pipeListener) OnMessage(ctx context.Context, msg MessageView, userId string) error {
+	l.messages <- msg
+	return nil
+}
+
+func (l *pipeListener) OnAckReceipt(ctx context.Context, msg MessageView, userId string) error {
+	return nil
+}
+
+func (l *pipeListener) SyncAck() bool {
+	return true
+}
+
+func TestBlazePipe(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, key, _ := ed25519.GenerateKey(rand.Reader)
+	client, server := NewBlazePipe()
+	b := NewBlazeClient(UuidNewV4().String(), UuidNewV4().String(), hex.EncodeToString(key.Seed()))
+	var authorization string
+	b.SetTransport(func(ctx context.Context, url string, header http.Header) (BlazeTransport, error) {
+		authorization = header.Get("Authorization")
+		return client, nil
+	})
+
+	read := func() *BlazeMessage {
+		data, err := server.ReadMessage(ctx)
+		if err != nil {
+			return nil
+		}
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		assert.Nil(err)
+		data, err = io.ReadAll(r)
+		assert.Nil(err)
+		var msg BlazeMessage
+		assert.Nil(json.Unmarshal(data, &msg))
+		return &msg
+	}
+	write := func(msg *BlazeMessage) {
+		data, err := json.Marshal(msg)
+		assert.Nil(err)
+		var buf bytes.Buffer
+		w := gzip.NewWriter(&buf)
+		w.Write(data)
+		w.Close()
+		assert.Nil(server.WriteMessage(ctx, buf.Bytes()))
+	}
+
+	l := &pipeListener{messages: make(chan MessageView, 1)}
+	lctx, stop := context.WithCancel(ctx)
+	result := make(chan error, 1)
+	go func() { result <- b.Loop(lctx, l) }()
+
+	list := read()
+	assert.Equal("LIST_PENDING_MESSAGES", list.Action)
+	write(&BlazeMessage{Id: list.Id, Action: list.Action, Data: json.RawMessage("{}")})
+	data, _ := json.Marshal(MessageView{MessageId: "m", Category: MessageCategoryPlainText})
+	write(&BlazeMessage{Id: "push", Action: "CREATE_MESSAGE", Data: data})
+	assert.Equal("m", (<-l.messages).MessageId)
+	ack := read()
+	assert.Equal("ACKNOWLEDGE_MESSAGE_RECEIPT", ack.Action)
+	assert.Equal("m", ack.Params["message_id"])
+	write(&BlazeMessage{Id: ack.Id, Action: ack.Action, Data: json.RawMessage("{}")})
+
+	// the shutdown is read as the end of the pipe, and closed in reply
+	stop()
+	assert.Nil(read())
+	server.Close()
+	assert.ErrorIs(<-result, context.Canceled)
+	assert.Contains(authorization, "Bearer ")
+}
+
+func TestCoderTransport(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the server never reads, so it answers neither pings nor close frames
+	stop := make(chan struct{})
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: []string{blazeSubprotocol}})
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		<-stop
+	}))
+	defer s.Close()
+	defer close(stop)
+
+	conn, err := CoderBlazeDialer(nil)(ctx, "ws"+strings.TrimPrefix(s.URL, "http"), nil)
+	assert.Nil(err)
+	ct := conn.(*coderTransport)
+	assert.Nil(ct.Ping(ctx))
+	assert.True(ct.pinging.Load())
+	assert.Nil(ct.Ping(ctx))
+
+	sctx, cancelShutdown := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancelShutdown()
+	start := time.Now()
+	assert.ErrorIs(ct.Shutdown(sctx), context.DeadlineExceeded)
+	assert.Less(time.Since(start), time.Second)
+}
@@ -56,10 +56,13 @@ func (c *MapCache) Delete(key string) error {
 }
 
 func NewBotAuthClient(cache BotAuthCache, su *SafeUser, logger *slog.Logger) *BotAuthClient {
+	if logger == nil {
+		logger = slog.Default()
+	}
 	return &BotAuthClient{
 		Cache:    cache,
 		SafeUser: su,
-		Logger:   logger,
+		Logger:   slog.New(NewRedactHandler(logger.Handler())),
 	}
 }
 
@@ -103,7 +106,7 @@ func (c *BotAuthClient) getSharedKey(ctx context.Context, userId string) ([]byte
 	value, err := c.Cache.Get(userId)
 	var sharedKey []byte
 	if err != nil || value == nil || len(value) < 32 {
-		c.Logger.Debug(fmt.Sprintf("cache miss for %s", userId))
+		c.Logger.DebugContext(ctx, "bot auth cache miss", "user_id", userId)
 		userSessions, err := FetchUserSession(ctx, []string{userId}, c.SafeUser)
 		if err != nil {
 			return nil, err
@@ -133,11 +136,11 @@ func (c *BotAuthClient) getSharedKey(ctx context.Context, userId string) ([]byte
 		}
 		err = c.Cache.Put(userId, sharedKey[:])
 		if err != nil {
-			c.Logger.Warn(fmt.Sprintf("save shared key for %s error %v", userId, err))
+			c.Logger.WarnContext(ctx, "bot auth save shared key", "user_id", userId, "error", err)
 		}
 		err = c.Cache.Put(fmt.Sprint(userPlatformPrefix, userId), []byte(platform))
 		if err != nil {
-			c.Logger.Warn(fmt.Sprintf("save platform for %s error %v", userId, err))
+			c.Logger.WarnContext(ctx, "bot auth save platform", "user_id", userId, "error", err)
 		}
 	} else {
 		sharedKey = value
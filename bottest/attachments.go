@@ -0,0 +1,63 @@
+package bottest
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+)
+
+func (s *Server) createAttachment(r *http.Request, uid string, body []byte) (any, error) {
+	id := bot.UuidNewV4().String()
+	s.attachments[id] = nil
+	return s.attachmentView(id), nil
+}
+
+func (s *Server) readAttachment(r *http.Request, uid string, body []byte) (any, error) {
+	id := r.PathValue("id")
+	if _, ok := s.attachments[id]; !ok {
+		return nil, bot.ErrNotFound
+	}
+	return s.attachmentView(id), nil
+}
+
+func (s *Server) attachmentView(id string) *bot.Attachment {
+	url := s.URL + "/blobs/" + id
+	return &bot.Attachment{Type: "attachment", AttachmentId: id, UploadUrl: url, ViewURL: url}
+}
+
+// serveBlob stores and serves the bytes of the attachments like the object
+// storage, without authentication.
+func (s *Server) serveBlob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	data, ok := s.attachments[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	switch r.Method {
+	case "PUT":
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.attachments[id] = data
+	case "GET":
+		if data == nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write(data)
+	}
+}
+
+// Attachment returns the uploaded bytes of the attachment.
+func (s *Server) Attachment(id string) []byte {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.attachments[id]
+}
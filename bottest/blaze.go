@@ -0,0 +1,463 @@
+package bottest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gorilla/websocket"
+)
+
+const blazeSubprotocol = "Mixin-Blaze-1"
+
+// BlazeServer is a fake Blaze websocket server. Messages pushed to a user are
+// delivered after the bot sends LIST_PENDING_MESSAGES, and are delivered again
+// after a reconnect until the bot acknowledges them.
+type BlazeServer struct {
+	Host string
+
+	api      *Server
+	server   *httptest.Server
+	upgrader websocket.Upgrader
+
+	mutex    sync.Mutex
+	conns    map[string]*blazeConn
+	pending  map[string][]*bot.MessageView
+	frames   []*Frame
+	faults   []*blazeFault
+	closes   map[string]bool
+	notifier chan struct{}
+}
+
+// Frame is a Blaze message received from a bot.
+type Frame struct {
+	bot.BlazeMessage
+	UserId string
+}
+
+type blazeFault struct {
+	action     string
+	code       int
+	disconnect bool
+	silent     bool
+}
+
+type blazeConn struct {
+	userId string
+	conn   *websocket.Conn
+	mutex  sync.Mutex
+	listed bool
+}
+
+// NewBlazeServer starts a Blaze server on TLS, because the bot always dials
+// wss. The sessions are checked against the users of api, or any token is
+// accepted when api is nil.
+func NewBlazeServer(api *Server) *BlazeServer {
+	b := &BlazeServer{
+		api:      api,
+		upgrader: websocket.Upgrader{Subprotocols: []string{blazeSubprotocol}},
+		conns:    make(map[string]*blazeConn),
+		pending:  make(map[string][]*bot.MessageView),
+		closes:   make(map[string]bool),
+		notifier: make(chan struct{}),
+	}
+	if api != nil {
+		api.mutex.Lock()
+		api.acknowledged = b.acknowledge
+		api.mutex.Unlock()
+	}
+	b.server = httptest.NewTLSServer(http.HandlerFunc(b.serve))
+	b.Host = strings.TrimPrefix(b.server.URL, "https://")
+	return b
+}
+
+func (b *BlazeServer) Close() {
+	b.mutex.Lock()
+	for _, c := range b.conns {
+		c.conn.Close()
+	}
+	b.mutex.Unlock()
+	b.server.Close()
+}
+
+// Dialer trusts the certificate of the server.
+func (b *BlazeServer) Dialer() *websocket.Dialer {
+	tr := b.server.Client().Transport.(*http.Transport)
+	return &websocket.Dialer{TLSClientConfig: &tls.Config{RootCAs: tr.TLSClientConfig.RootCAs}}
+}
+
+// Client returns a Blaze client of su connected to this server, and to the
+// API server for the requests not sent over Blaze.
+func (b *BlazeServer) Client(su *bot.SafeUser) *bot.BlazeClient {
+	c := bot.NewClient(su)
+	if b.api != nil {
+		c = b.api.Client(su)
+	}
+	c.SetBlazeUri(b.Host)
+	bc := c.NewBlazeClient()
+	bc.SetupDailer(b.Dialer())
+	return bc
+}
+
+// Hub returns a Blaze hub connecting its bots to this server.
+func (b *BlazeServer) Hub(s *bot.BlazeSupervisor) *bot.BlazeHub {
+	c := bot.NewClient(nil)
+	if b.api != nil {
+		c = b.api.Client(nil)
+	}
+	c.SetBlazeUri(b.Host)
+	h := c.NewBlazeHub(s)
+	h.SetupDailer(b.Dialer())
+	return h
+}
+
+// PushMessage sends a CREATE_MESSAGE to the user, the message id, status
+// and timestamps are filled when empty.
+func (b *BlazeServer) PushMessage(userId string, msg bot.MessageView) *bot.MessageView {
+	if msg.MessageId == "" {
+		msg.MessageId = bot.UuidNewV4().String()
+	}
+	if msg.CreatedAt.IsZero() {
+		msg.CreatedAt = time.Now().UTC()
+		msg.UpdatedAt = msg.CreatedAt
+	}
+	if msg.Status == "" {
+		msg.Status = "SENT"
+	}
+	msg.Source = "CREATE_MESSAGE"
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.pending[userId] = append(b.pending[userId], &msg)
+	if c := b.conns[userId]; c != nil && c.listed {
+		c.send("CREATE_MESSAGE", &msg)
+	}
+	return &msg
+}
+
+// PushAckReceipt tells the user that messageId changed to status, e.g.
+// DELIVERED or READ. Receipts are not queued for offline users.
+func (b *BlazeServer) PushAckReceipt(userId, messageId, status string) {
+	msg := &bot.MessageView{
+		MessageId: messageId,
+		Status:    status,
+		Source:    "ACKNOWLEDGE_MESSAGE_RECEIPT",
+		UpdatedAt: time.Now().UTC(),
+	}
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if c := b.conns[userId]; c != nil {
+		c.send("ACKNOWLEDGE_MESSAGE_RECEIPT", msg)
+	}
+}
+
+// InjectError makes the server answer the next frame of action with an
+// error of code instead of a success.
+func (b *BlazeServer) InjectError(action string, code int) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.faults = append(b.faults, &blazeFault{action: action, code: code})
+}
+
+// InjectDisconnect makes the server drop the connection when it receives
+// the next frame of action, without answering it.
+func (b *BlazeServer) InjectDisconnect(action string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.faults = append(b.faults, &blazeFault{action: action, disconnect: true})
+}
+
+// InjectTimeout makes the server ignore the next frame of action, so the bot
+// times out waiting for the reply.
+func (b *BlazeServer) InjectTimeout(action string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.faults = append(b.faults, &blazeFault{action: action, silent: true})
+}
+
+// Disconnect drops the connection of the user without a close frame.
+func (b *BlazeServer) Disconnect(userId string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if c := b.conns[userId]; c != nil {
+		c.conn.Close()
+		delete(b.conns, userId)
+	}
+}
+
+// Connected reports whether the user has listed its pending messages on a
+// live connection.
+func (b *BlazeServer) Connected(userId string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	c := b.conns[userId]
+	return c != nil && c.listed
+}
+
+// ClosedNormally tells whether the last connection of the user ended with a
+// normal close frame from the bot.
+func (b *BlazeServer) ClosedNormally(userId string) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.closes[userId]
+}
+
+// Frames returns every frame received from the bots so far in order.
+func (b *BlazeServer) Frames() []*Frame {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	frames := make([]*Frame, len(b.frames))
+	copy(frames, b.frames)
+	return frames
+}
+
+// Pending returns the messages of the user which are not acknowledged yet.
+func (b *BlazeServer) Pending(userId string) []*bot.MessageView {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return slices.Clone(b.pending[userId])
+}
+
+// Wait blocks until cond is true or ctx is done, cond is checked after every
+// change of the server state and at least every 10 milliseconds.
+func (b *BlazeServer) Wait(ctx context.Context, cond func() bool) error {
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		b.mutex.Lock()
+		notifier := b.notifier
+		b.mutex.Unlock()
+		if cond() {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notifier:
+		case <-ticker.C:
+		}
+	}
+}
+
+// notify wakes up all waiters, it must be called with the mutex held.
+func (b *BlazeServer) notify() {
+	close(b.notifier)
+	b.notifier = make(chan struct{})
+}
+
+func (b *BlazeServer) serve(w http.ResponseWriter, r *http.Request) {
+	if slices.Contains(websocket.Subprotocols(r), oauthSubprotocol) {
+		b.serveOAuth(w, r)
+		return
+	}
+	if !slices.Contains(websocket.Subprotocols(r), blazeSubprotocol) {
+		http.Error(w, "invalid subprotocol", http.StatusBadRequest)
+		return
+	}
+	userId, err := b.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	conn, err := b.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	c := &blazeConn{userId: userId, conn: conn}
+	b.mutex.Lock()
+	if old := b.conns[userId]; old != nil {
+		old.conn.Close()
+	}
+	b.conns[userId] = c
+	b.notify()
+	b.mutex.Unlock()
+
+	normal := false
+	defer func() {
+		conn.Close()
+		b.mutex.Lock()
+		if b.conns[userId] == c {
+			delete(b.conns, userId)
+		}
+		b.closes[userId] = normal
+		b.notify()
+		b.mutex.Unlock()
+	}()
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil {
+			normal = websocket.IsCloseError(err, websocket.CloseNormalClosure)
+			return
+		}
+		if typ != websocket.BinaryMessage {
+			return
+		}
+		msg, err := decodeBlazeMessage(data)
+		if err != nil {
+			return
+		}
+		if !b.handle(c, msg, b.check(msg)) {
+			return
+		}
+	}
+}
+
+func (b *BlazeServer) authenticate(r *http.Request) (string, error) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if b.api == nil {
+		return tokenUserId(token), nil
+	}
+	b.api.mutex.Lock()
+	defer b.api.mutex.Unlock()
+	return b.api.verifyToken(token, "GET", "/", nil)
+}
+
+// check validates the messages created by the bots against the API server,
+// before handle takes the mutex of the Blaze server.
+func (b *BlazeServer) check(msg *bot.BlazeMessage) error {
+	if b.api == nil || msg.Action != "CREATE_MESSAGE" {
+		return nil
+	}
+	data, err := json.Marshal(msg.Params)
+	if err != nil {
+		return err
+	}
+	var req bot.MessageRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		return bot.ErrBadData
+	}
+	b.api.mutex.Lock()
+	defer b.api.mutex.Unlock()
+	return b.api.checkMessage(&req)
+}
+
+// handle records the frame and answers it, or answers the error of check
+// when it's not nil. It returns false when the connection should be dropped.
+func (b *BlazeServer) handle(c *blazeConn, msg *bot.BlazeMessage, check error) bool {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.frames = append(b.frames, &Frame{BlazeMessage: *msg, UserId: c.userId})
+	defer b.notify()
+
+	for i, f := range b.faults {
+		if f.action != msg.Action {
+			continue
+		}
+		b.faults = slices.Delete(b.faults, i, i+1)
+		if f.disconnect {
+			return false
+		}
+		if f.silent {
+			return true
+		}
+		e := bot.ErrBlazeServer
+		e.Code = f.code
+		return c.write(&bot.BlazeMessage{Id: msg.Id, Action: msg.Action, Error: &e}) == nil
+	}
+
+	if check != nil {
+		e, ok := bot.AsError(check)
+		if !ok {
+			e = bot.ErrBadData
+		}
+		return c.write(&bot.BlazeMessage{Id: msg.Id, Action: msg.Action, Error: &e}) == nil
+	}
+
+	switch msg.Action {
+	case "ACKNOWLEDGE_MESSAGE_RECEIPT":
+		id, _ := msg.Params["message_id"].(string)
+		b.removePending(c.userId, []string{id})
+	case "ACKNOWLEDGE_MESSAGE_RECEIPTS":
+		messages, _ := msg.Params["messages"].([]any)
+		var ids []string
+		for _, m := range messages {
+			m, _ := m.(map[string]any)
+			id, _ := m["message_id"].(string)
+			ids = append(ids, id)
+		}
+		b.removePending(c.userId, ids)
+	}
+	if c.write(&bot.BlazeMessage{Id: msg.Id, Action: msg.Action, Data: json.RawMessage("{}")}) != nil {
+		return false
+	}
+	if msg.Action == "LIST_PENDING_MESSAGES" {
+		c.listed = true
+		for _, m := range b.pending[c.userId] {
+			c.send("CREATE_MESSAGE", m)
+		}
+	}
+	return true
+}
+
+// acknowledge handles the acknowledgements posted to the API server.
+func (b *BlazeServer) acknowledge(userId string, ids []string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	b.removePending(userId, ids)
+	b.notify()
+}
+
+func (b *BlazeServer) removePending(userId string, ids []string) {
+	b.pending[userId] = slices.DeleteFunc(b.pending[userId], func(m *bot.MessageView) bool {
+		return slices.Contains(ids, m.MessageId)
+	})
+}
+
+func (c *blazeConn) send(action string, msg *bot.MessageView) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.write(&bot.BlazeMessage{Id: bot.UuidNewV4().String(), Action: action, Data: data})
+}
+
+func (c *blazeConn) write(msg *bot.BlazeMessage) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(data); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	return c.conn.WriteMessage(websocket.BinaryMessage, buf.Bytes())
+}
+
+func tokenUserId(token string) string {
+	claims := jwt.MapClaims{}
+	jwt.NewParser().ParseUnverified(token, claims)
+	uid, _ := claims["uid"].(string)
+	return uid
+}
+
+func decodeBlazeMessage(data []byte) (*bot.BlazeMessage, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	b, err := io.ReadAll(gz)
+	if err != nil {
+		return nil, err
+	}
+	var msg bot.BlazeMessage
+	return &msg, json.Unmarshal(b, &msg)
+}
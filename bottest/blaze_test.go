@@ -0,0 +1,932 @@
+package bottest
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/coder/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+type testListener struct {
+	mutex    sync.Mutex
+	messages []bot.MessageView
+	receipts []bot.MessageView
+}
+
+func (l *testListener) OnMessage(ctx context.Context, msg bot.MessageView, userId string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.messages = append(l.messages, msg)
+	return nil
+}
+
+func (l *testListener) OnAckReceipt(ctx context.Context, msg bot.MessageView, userId string) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.receipts = append(l.receipts, msg)
+	return nil
+}
+
+func (l *testListener) SyncAck() bool {
+	return true
+}
+
+func (l *testListener) count() (int, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return len(l.messages), len(l.receipts)
+}
+
+func TestBlazeServer(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	first := b.PushMessage(app.UserId, bot.MessageView{
+		ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+		UserId:         user.UserId,
+		Category:       bot.MessageCategoryPlainText,
+		DataBase64:     base64.RawURLEncoding.EncodeToString([]byte("hi")),
+	})
+
+	client := b.Client(app)
+	listener := &testListener{}
+	done := make(chan error, 1)
+	go func() { done <- client.Loop(ctx, listener) }()
+
+	err := b.Wait(ctx, func() bool { return len(b.Pending(app.UserId)) == 0 })
+	assert.Nil(err)
+	b.PushMessage(app.UserId, bot.MessageView{UserId: user.UserId, Category: bot.MessageCategoryPlainText})
+	b.PushAckReceipt(app.UserId, first.MessageId, "READ")
+	err = b.Wait(ctx, func() bool {
+		messages, receipts := listener.count()
+		return messages == 2 && receipts == 1 && len(b.Pending(app.UserId)) == 0
+	})
+	assert.Nil(err)
+	assert.Equal(first.MessageId, listener.messages[0].MessageId)
+	assert.Equal("READ", listener.receipts[0].Status)
+
+	b.InjectError("CREATE_MESSAGE", 500)
+	err = client.SendMessage(ctx, first.ConversationId, user.UserId, bot.UuidNewV4().String(), bot.MessageCategoryPlainText, "hello", "")
+	assert.Nil(err)
+
+	var actions []string
+	for _, f := range b.Frames() {
+		assert.Equal(app.UserId, f.UserId)
+		actions = append(actions, f.Action)
+	}
+	assert.Equal([]string{
+		"LIST_PENDING_MESSAGES",
+		"ACKNOWLEDGE_MESSAGE_RECEIPT",
+		"ACKNOWLEDGE_MESSAGE_RECEIPT",
+		"CREATE_MESSAGE",
+		"CREATE_MESSAGE",
+	}, actions)
+
+	b.Disconnect(app.UserId)
+	select {
+	case err := <-done:
+		assert.Nil(err)
+	case <-ctx.Done():
+		t.Fatal("loop not stopped after disconnect")
+	}
+}
+
+func TestBlazeSupervisor(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	var mutex sync.Mutex
+	var states []bot.BlazeState
+	s := &bot.BlazeSupervisor{
+		MaxRetries: 2,
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+		OnStateChange: func(state bot.BlazeState, err error) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			states = append(states, state)
+		},
+	}
+	l := &testListener{}
+	done := make(chan error, 1)
+	go func() { done <- b.Client(app).Supervise(ctx, l, s) }()
+
+	assert.Nil(b.Wait(ctx, func() bool { return b.Connected(app.UserId) }))
+	b.Disconnect(app.UserId)
+	b.PushMessage(app.UserId, bot.MessageView{
+		ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+		UserId:         user.UserId,
+		Category:       bot.MessageCategoryPlainText,
+		DataBase64:     base64.RawURLEncoding.EncodeToString([]byte("hi")),
+	})
+	assert.Nil(b.Wait(ctx, func() bool {
+		n, _ := l.count()
+		return n == 1 && len(b.Pending(app.UserId)) == 0
+	}))
+
+	b.Close()
+	err := <-done
+	assert.ErrorIs(err, bot.ErrBlazeRetryBudget)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	assert.Equal([]bot.BlazeState{
+		bot.BlazeStateConnecting, bot.BlazeStateConnected, bot.BlazeStateDegraded,
+		bot.BlazeStateConnecting, bot.BlazeStateConnected, bot.BlazeStateDegraded,
+		bot.BlazeStateConnecting, bot.BlazeStateDegraded,
+		bot.BlazeStateConnecting, bot.BlazeStateClosed,
+	}, states)
+}
+
+type orderedListener struct {
+	mutex   sync.Mutex
+	running int
+	peak    int
+	texts   map[string][]string
+	failed  bool
+}
+
+func (l *orderedListener) OnMessage(ctx context.Context, msg bot.MessageView, userId string) error {
+	p, err := msg.Decode()
+	if err != nil {
+		return err
+	}
+	l.mutex.Lock()
+	if p.Text == "fail" && !l.failed {
+		l.failed = true
+		l.mutex.Unlock()
+		return fmt.Errorf("fail %s", msg.MessageId)
+	}
+	l.running++
+	l.peak = max(l.peak, l.running)
+	l.mutex.Unlock()
+
+	time.Sleep(20 * time.Millisecond)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.running--
+	l.texts[msg.ConversationId] = append(l.texts[msg.ConversationId], p.Text)
+	return nil
+}
+
+func (l *orderedListener) OnAckReceipt(ctx context.Context, msg bot.MessageView, userId string) error {
+	return nil
+}
+
+func (l *orderedListener) SyncAck() bool {
+	return true
+}
+
+func TestBlazeConcurrency(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app := api.CreateUser("app")
+	var conversations []string
+	for i := range 4 {
+		user := api.CreateUser(fmt.Sprintf("user %d", i))
+		conversations = append(conversations, bot.UniqueConversationId(app.UserId, user.UserId))
+	}
+	for i := range 5 {
+		for _, c := range conversations {
+			text := fmt.Sprint(i)
+			if i == 2 && c == conversations[1] {
+				text = "fail"
+			}
+			b.PushMessage(app.UserId, bot.MessageView{
+				ConversationId: c,
+				Category:       bot.MessageCategoryPlainText,
+				DataBase64:     base64.StdEncoding.EncodeToString([]byte(text)),
+			})
+		}
+	}
+
+	l := &orderedListener{texts: make(map[string][]string)}
+	client := b.Client(app)
+	client.SetConcurrency(8, 2)
+	s := &bot.BlazeSupervisor{MinBackoff: 10 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+	go client.Supervise(ctx, l, s)
+
+	assert.Nil(b.Wait(ctx, func() bool { return len(b.Pending(app.UserId)) == 0 }))
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	assert.True(l.failed)
+	assert.Greater(l.peak, 1)
+	// messages handled but not acknowledged before the failure are delivered
+	// again, in order as well
+	for _, c := range conversations {
+		var texts []string
+		for _, t := range l.texts[c] {
+			if !slices.Contains(texts, t) {
+				texts = append(texts, t)
+			}
+		}
+		if c == conversations[1] {
+			assert.Equal([]string{"0", "1", "fail", "3", "4"}, texts)
+		} else {
+			assert.Equal([]string{"0", "1", "2", "3", "4"}, texts)
+		}
+	}
+}
+
+type stopListener struct {
+	testListener
+	stop string
+}
+
+func (l *stopListener) OnMessage(ctx context.Context, msg bot.MessageView, userId string) error {
+	if msg.MessageId == l.stop {
+		return fmt.Errorf("stop %s", msg.MessageId)
+	}
+	return l.testListener.OnMessage(ctx, msg, userId)
+}
+
+func TestBlazeAcknowledger(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	var last *bot.MessageView
+	for i := range 4 {
+		last = b.PushMessage(app.UserId, bot.MessageView{
+			ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+			UserId:         user.UserId,
+			Category:       bot.MessageCategoryPlainText,
+			DataBase64:     base64.StdEncoding.EncodeToString([]byte(fmt.Sprint(i))),
+		})
+	}
+
+	client := b.Client(app)
+	a := client.NewAcknowledger(10, time.Hour)
+	l := &stopListener{stop: last.MessageId}
+	err := client.Loop(ctx, l)
+	assert.ErrorContains(err, "stop")
+
+	// the receipts are flushed in one batch when the loop stops
+	pending := b.Pending(app.UserId)
+	assert.Len(pending, 1)
+	assert.Equal(last.MessageId, pending[0].MessageId)
+	var actions []string
+	for _, f := range b.Frames() {
+		actions = append(actions, f.Action)
+	}
+	assert.Equal([]string{"LIST_PENDING_MESSAGES", "ACKNOWLEDGE_MESSAGE_RECEIPTS"}, actions)
+
+	// without a connection the receipts are posted to the API
+	a.Ack(last.MessageId, bot.AckStatusDelivered)
+	a.Ack(last.MessageId, bot.AckStatusRead)
+	a.Ack(last.MessageId, bot.AckStatusDelivered)
+	assert.Equal(1, a.Pending())
+	assert.Nil(a.Flush(ctx))
+	assert.Equal(0, a.Pending())
+	assert.Len(b.Pending(app.UserId), 0)
+}
+
+func TestBlazeEncryption(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	seed, _ := hex.DecodeString(user.SessionPrivateKey)
+	private := base64.RawURLEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed))
+	sessions, err := api.Client(user).FetchUserSession(ctx, []string{app.UserId})
+	assert.Nil(err)
+	assert.Len(sessions, 1)
+	data, err := bot.EncryptMessageData(base64.RawURLEncoding.EncodeToString([]byte("secret")), []*bot.Session{{
+		UserID:    sessions[0].UserId,
+		SessionID: sessions[0].SessionId,
+		PublicKey: sessions[0].PublicKey,
+	}}, private)
+	assert.Nil(err)
+	b.PushMessage(app.UserId, bot.MessageView{
+		ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+		UserId:         user.UserId,
+		Category:       "ENCRYPTED_TEXT",
+		DataBase64:     data,
+	})
+
+	l := &testListener{}
+	client := b.Client(app)
+	client.SetEncryption(true)
+	go client.Loop(ctx, l)
+	assert.Nil(b.Wait(ctx, func() bool {
+		n, _ := l.count()
+		return n == 1
+	}))
+	l.mutex.Lock()
+	msg := l.messages[0]
+	l.mutex.Unlock()
+	p, err := msg.Decode()
+	assert.Nil(err)
+	assert.Equal(bot.MessageCategoryPlainText, p.Category)
+	assert.Equal("secret", p.Text)
+
+	err = client.SendPlainText(ctx, msg, "reply")
+	assert.Nil(err)
+	api.AddSession(user)
+	err = client.SendPlainText(ctx, msg, "again")
+	assert.Nil(err)
+	var created []*Frame
+	for _, f := range b.Frames() {
+		if f.Action == "CREATE_MESSAGE" {
+			created = append(created, f)
+		}
+	}
+	assert.Len(created, 3)
+	for _, f := range created {
+		assert.Equal("ENCRYPTED_TEXT", f.Params["category"])
+	}
+	assert.Len(created[1].Params["recipient_sessions"], 1)
+	assert.Len(created[2].Params["recipient_sessions"], 2)
+}
+
+func TestBlazeMessageBuilder(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	client := b.Client(app)
+	go client.Loop(ctx, &testListener{})
+	assert.Nil(b.Wait(ctx, func() bool { return b.Connected(app.UserId) }))
+
+	msg, err := bot.NewMessage(bot.UniqueConversationId(app.UserId, user.UserId), user.UserId).
+		MessageId(bot.UniqueConversationId("order", "42")).
+		Silent(true).
+		Location(121.47, 31.23, "Shanghai", "").
+		Build()
+	assert.Nil(err)
+	assert.Nil(client.SendMessageRequest(ctx, msg))
+	assert.Nil(api.Client(app).PostMessageRequest(ctx, msg))
+
+	err = client.SendAppCard(ctx, msg.ConversationId, user.UserId, "Title", "Description", "https://mixin.one", "")
+	assert.Nil(err)
+
+	var created []*Frame
+	for _, f := range b.Frames() {
+		if f.Action == "CREATE_MESSAGE" {
+			created = append(created, f)
+		}
+	}
+	assert.Len(created, 2)
+	assert.Equal(msg.MessageId, created[0].Params["message_id"])
+	assert.Equal(true, created[0].Params["silent"])
+	assert.Equal(msg.MessageId, api.Messages()[0].MessageId)
+	assert.Equal(msg.DataBase64, api.Messages()[0].DataBase64)
+
+	card, err := base64.RawURLEncoding.DecodeString(created[1].Params["data_base64"].(string))
+	assert.Nil(err)
+	assert.JSONEq(`{"title":"Title","description":"Description","action":"https://mixin.one","icon_url":""}`, string(card))
+}
+
+func TestBlazeActionRetry(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	client := b.Client(app)
+	client.SetActionTimeout(100 * time.Millisecond)
+	client.SetActionRetryPolicy(&bot.RetryPolicy{MaxAttempts: 2})
+	go client.Loop(ctx, &testListener{})
+	assert.Nil(b.Wait(ctx, func() bool { return b.Connected(app.UserId) }))
+
+	created := func() []*Frame {
+		var frames []*Frame
+		for _, f := range b.Frames() {
+			if f.Action == "CREATE_MESSAGE" {
+				frames = append(frames, f)
+			}
+		}
+		return frames
+	}
+	conversationId := bot.UniqueConversationId(app.UserId, user.UserId)
+
+	// a reply timeout is retried with the same id
+	b.InjectTimeout("CREATE_MESSAGE")
+	assert.Nil(client.SendPlainText(ctx, bot.MessageView{ConversationId: conversationId, UserId: user.UserId}, "hi"))
+	frames := created()
+	assert.Len(frames, 2)
+	assert.Equal(frames[0].Id, frames[1].Id)
+
+	// the attempts are bounded by the policy
+	b.InjectTimeout("CREATE_MESSAGE")
+	b.InjectError("CREATE_MESSAGE", bot.ErrorCodeInternalServer)
+	err := client.SendPlainText(ctx, bot.MessageView{ConversationId: conversationId, UserId: user.UserId}, "hi")
+	assert.NotNil(err)
+	assert.Len(created(), 4)
+
+	client.SetActionRetryPolicy(nil)
+	b.InjectTimeout("CREATE_MESSAGE")
+	err = client.SendPlainText(ctx, bot.MessageView{ConversationId: conversationId, UserId: user.UserId}, "hi")
+	assert.NotNil(err)
+	assert.Len(created(), 5)
+}
+
+func TestBlazeOutbox(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	client := b.Client(app)
+	var mutex sync.Mutex
+	var dead []string
+	client.SetOutbox(2, &bot.RetryPolicy{MaxAttempts: 1}, func(msg *bot.MessageRequest, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		dead = append(dead, msg.MessageId)
+	})
+
+	// the messages enqueued offline are sent once connected
+	text := func(content string) *bot.MessageRequest {
+		msg, err := bot.NewMessage(bot.UniqueConversationId(app.UserId, user.UserId), user.UserId).Text(content).Build()
+		assert.Nil(err)
+		return msg
+	}
+	failed, sent := text("failed"), text("sent")
+	assert.Nil(client.Enqueue(failed))
+	assert.Nil(client.Enqueue(sent))
+	assert.ErrorIs(client.Enqueue(text("full")), bot.ErrOutboxFull)
+
+	// each attempt of the outbox retries the action 5 times
+	for range 5 {
+		b.InjectError("CREATE_MESSAGE", bot.ErrorCodeInternalServer)
+	}
+	l := &testListener{}
+	go client.Loop(ctx, l)
+	assert.Nil(b.Wait(ctx, func() bool { return client.MessageStatus(sent.MessageId) == bot.MessageStatusSent }))
+	mutex.Lock()
+	assert.Equal([]string{failed.MessageId}, dead)
+	mutex.Unlock()
+	assert.Equal(bot.MessageStatusFailed, client.MessageStatus(failed.MessageId))
+	assert.Equal("", client.MessageStatus(text("unknown").MessageId))
+
+	b.PushAckReceipt(app.UserId, sent.MessageId, bot.MessageStatusDelivered)
+	b.PushAckReceipt(app.UserId, sent.MessageId, bot.MessageStatusRead)
+	b.PushAckReceipt(app.UserId, sent.MessageId, bot.MessageStatusDelivered)
+	assert.Nil(b.Wait(ctx, func() bool {
+		_, receipts := l.count()
+		return receipts == 3
+	}))
+	assert.Equal(bot.MessageStatusRead, client.MessageStatus(sent.MessageId))
+}
+
+func TestBlazeDedupStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	store, err := bot.OpenFileDedupStore(filepath.Join(t.TempDir(), "dedup"), time.Hour)
+	assert.Nil(err)
+	defer store.Close()
+
+	push := func(id string) *bot.MessageView {
+		return b.PushMessage(app.UserId, bot.MessageView{
+			ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+			UserId:         user.UserId,
+			MessageId:      id,
+			Category:       bot.MessageCategoryPlainText,
+			DataBase64:     base64.StdEncoding.EncodeToString([]byte("refund")),
+		})
+	}
+	first := push("")
+	push(first.MessageId)
+	stop := push("")
+
+	client := b.Client(app)
+	client.SetDedupStore(store)
+	l := &stopListener{stop: stop.MessageId}
+	err = client.Loop(ctx, l)
+	assert.ErrorContains(err, "stop")
+	messages, _ := l.count()
+	assert.Equal(1, messages)
+	seen, err := store.Seen(ctx, first.MessageId)
+	assert.Nil(err)
+	assert.True(seen)
+	seen, _ = store.Seen(ctx, stop.MessageId)
+	assert.False(seen)
+
+	// the duplicates are acknowledged without calling the handler
+	pending := b.Pending(app.UserId)
+	assert.Len(pending, 1)
+	assert.Equal(stop.MessageId, pending[0].MessageId)
+}
+
+func TestBlazeHub(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	var mutex sync.Mutex
+	states := make(map[string]bot.BlazeState)
+	hub := b.Hub(&bot.BlazeSupervisor{MinBackoff: 10 * time.Millisecond, MaxBackoff: 100 * time.Millisecond})
+	hub.SetBufferSize(4)
+	hub.OnStateChange = func(userId string, state bot.BlazeState, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		states[userId] = state
+	}
+	defer hub.Close()
+
+	user := api.CreateUser("user")
+	bots := make(map[string]*testListener)
+	add := func(name string) *bot.SafeUser {
+		su := api.CreateUser(name)
+		bots[su.UserId] = &testListener{}
+		assert.Nil(hub.Add(hub.NewBlazeClient(su), bots[su.UserId]))
+		return su
+	}
+	push := func(su *bot.SafeUser, count int) {
+		for range count {
+			b.PushMessage(su.UserId, bot.MessageView{
+				ConversationId: bot.UniqueConversationId(su.UserId, user.UserId),
+				UserId:         user.UserId,
+				Category:       bot.MessageCategoryPlainText,
+				DataBase64:     base64.StdEncoding.EncodeToString([]byte("hi")),
+			})
+		}
+	}
+	received := func(su *bot.SafeUser, count int) func() bool {
+		return func() bool {
+			messages, _ := bots[su.UserId].count()
+			return messages >= count
+		}
+	}
+
+	first, second, third := add("first"), add("second"), add("third")
+	assert.ErrorContains(hub.Add(hub.NewBlazeClient(first), &testListener{}), "already added")
+	assert.Len(hub.Bots(), 3)
+	for _, su := range []*bot.SafeUser{first, second, third} {
+		push(su, 10)
+		assert.Nil(b.Wait(ctx, received(su, 10)))
+	}
+	mutex.Lock()
+	assert.Equal(bot.BlazeStateConnected, states[second.UserId])
+	mutex.Unlock()
+
+	// a bot removed at runtime is disconnected, the others keep running
+	assert.Nil(hub.Remove(second.UserId))
+	assert.ErrorContains(hub.Remove(second.UserId), "not found")
+	assert.Nil(b.Wait(ctx, func() bool { return !b.Connected(second.UserId) }))
+	push(second, 1)
+	push(first, 1)
+	assert.Nil(b.Wait(ctx, received(first, 11)))
+	assert.Len(b.Pending(second.UserId), 1)
+
+	fourth := add("fourth")
+	push(fourth, 1)
+	assert.Nil(b.Wait(ctx, received(fourth, 1)))
+	assert.Equal(hub.Bots(), slices.Sorted(slices.Values([]string{first.UserId, third.UserId, fourth.UserId})))
+
+	// a bot dropped by the server reconnects with the policy of the hub
+	b.Disconnect(third.UserId)
+	push(third, 1)
+	assert.Nil(b.Wait(ctx, received(third, 11)))
+
+	hub.Close()
+	assert.Len(hub.Bots(), 0)
+	assert.ErrorContains(hub.Add(hub.NewBlazeClient(second), &testListener{}), "closed")
+	for _, su := range []*bot.SafeUser{first, third, fourth} {
+		assert.Nil(b.Wait(ctx, func() bool { return !b.Connected(su.UserId) }))
+	}
+}
+
+type blockedListener struct {
+	testListener
+	unblock chan struct{}
+}
+
+func (l *blockedListener) OnMessage(ctx context.Context, msg bot.MessageView, userId string) error {
+	select {
+	case <-l.unblock:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return l.testListener.OnMessage(ctx, msg, userId)
+}
+
+func TestBlazeHubBudget(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	var mutex sync.Mutex
+	connects := make(map[string]int)
+	hub := b.Hub(&bot.BlazeSupervisor{MinBackoff: 10 * time.Millisecond, MaxBackoff: 100 * time.Millisecond})
+	hub.SetBufferSize(4)
+	hub.SetBudget(6)
+	hub.OnStateChange = func(userId string, state bot.BlazeState, err error) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if state == bot.BlazeStateConnected {
+			connects[userId]++
+		}
+	}
+	defer hub.Close()
+
+	user := api.CreateUser("user")
+	push := func(su *bot.SafeUser, count int) {
+		for range count {
+			b.PushMessage(su.UserId, bot.MessageView{
+				ConversationId: bot.UniqueConversationId(su.UserId, user.UserId),
+				UserId:         user.UserId,
+				Category:       bot.MessageCategoryPlainText,
+				DataBase64:     base64.StdEncoding.EncodeToString([]byte("hi")),
+			})
+		}
+	}
+
+	slow := api.CreateUser("slow")
+	blocked := &blockedListener{unblock: make(chan struct{})}
+	assert.Nil(hub.Add(hub.NewBlazeClient(slow), blocked))
+	fast := make(map[*bot.SafeUser]*testListener)
+	for _, name := range []string{"first", "second"} {
+		su := api.CreateUser(name)
+		fast[su] = &testListener{}
+		assert.Nil(hub.Add(hub.NewBlazeClient(su), fast[su]))
+	}
+	for su := range fast {
+		assert.Nil(b.Wait(ctx, func() bool { return b.Connected(su.UserId) }))
+	}
+
+	// the slow bot holds 5 messages of the budget, one in its handler is not
+	// counted, while the fast bots share the last one
+	push(slow, 12)
+	for su, l := range fast {
+		push(su, 10)
+		assert.Nil(b.Wait(ctx, func() bool {
+			messages, _ := l.count()
+			return messages == 10
+		}))
+	}
+
+	// the slow bot is not dropped after the action timeout, it waits for its
+	// handler and receives all messages once unblocked
+	time.Sleep(4 * time.Second)
+	assert.True(b.Connected(slow.UserId))
+	close(blocked.unblock)
+	assert.Nil(b.Wait(ctx, func() bool {
+		messages, _ := blocked.count()
+		return messages == 12
+	}))
+	mutex.Lock()
+	assert.Equal(1, connects[slow.UserId])
+	mutex.Unlock()
+}
+
+type slowListener struct {
+	testListener
+	delay   time.Duration
+	started chan string
+	done    chan error
+}
+
+func (l *slowListener) OnMessage(ctx context.Context, msg bot.MessageView, userId string) error {
+	l.started <- msg.MessageId
+	select {
+	case <-time.After(l.delay):
+	case <-ctx.Done():
+	}
+	l.done <- ctx.Err()
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return l.testListener.OnMessage(ctx, msg, userId)
+}
+
+func TestBlazeShutdown(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	push := func() {
+		b.PushMessage(app.UserId, bot.MessageView{
+			ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+			UserId:         user.UserId,
+			Category:       bot.MessageCategoryPlainText,
+			DataBase64:     base64.StdEncoding.EncodeToString([]byte("hi")),
+		})
+	}
+	run := func(client *bot.BlazeClient, l *slowListener) (context.CancelFunc, chan error) {
+		lctx, stop := context.WithCancel(ctx)
+		result := make(chan error, 1)
+		go func() { result <- client.Loop(lctx, l) }()
+		select {
+		case <-l.started:
+		case <-ctx.Done():
+		}
+		return stop, result
+	}
+
+	// the handler in progress finishes, and its receipt is flushed before
+	// the connection is closed with a close frame
+	push()
+	client := b.Client(app)
+	client.NewAcknowledger(10, time.Hour)
+	l := &slowListener{delay: 200 * time.Millisecond, started: make(chan string, 10), done: make(chan error, 10)}
+	stop, result := run(client, l)
+	stop()
+	assert.ErrorIs(<-result, context.Canceled)
+	assert.Nil(<-l.done)
+	assert.Len(b.Pending(app.UserId), 0)
+	frames := b.Frames()
+	assert.Equal("ACKNOWLEDGE_MESSAGE_RECEIPTS", frames[len(frames)-1].Action)
+	assert.Nil(b.Wait(ctx, func() bool { return !b.Connected(app.UserId) }))
+	assert.True(b.ClosedNormally(app.UserId))
+
+	// the handlers still running after the shutdown timeout are canceled
+	push()
+	client = b.Client(app)
+	client.SetConcurrency(2, 0)
+	client.SetShutdownTimeout(50 * time.Millisecond)
+	l = &slowListener{delay: time.Hour, started: make(chan string, 10), done: make(chan error, 10)}
+	stop, result = run(client, l)
+	start := time.Now()
+	stop()
+	assert.ErrorIs(<-result, context.Canceled)
+	assert.ErrorIs(<-l.done, context.Canceled)
+	assert.Less(time.Since(start), 5*time.Second)
+	assert.Len(b.Pending(app.UserId), 1)
+	assert.Nil(b.Wait(ctx, func() bool { return !b.Connected(app.UserId) }))
+	assert.True(b.ClosedNormally(app.UserId))
+}
+
+func TestBlazeCoderTransport(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	msg := b.PushMessage(app.UserId, bot.MessageView{
+		ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+		UserId:         user.UserId,
+		Category:       bot.MessageCategoryPlainText,
+		DataBase64:     base64.StdEncoding.EncodeToString([]byte("hi")),
+	})
+
+	client := b.Client(app)
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: b.Dialer().TLSClientConfig}}
+	client.SetTransport(bot.CoderBlazeDialer(&websocket.DialOptions{HTTPClient: hc}))
+	l := &slowListener{started: make(chan string, 10), done: make(chan error, 10)}
+	lctx, stop := context.WithCancel(ctx)
+	result := make(chan error, 1)
+	go func() { result <- client.Loop(lctx, l) }()
+	assert.Equal(msg.MessageId, <-l.started)
+	assert.Nil(<-l.done)
+
+	err := client.SendPlainText(ctx, *msg, "hello")
+	assert.Nil(err)
+	stop()
+	assert.ErrorIs(<-result, context.Canceled)
+	assert.Len(b.Pending(app.UserId), 0)
+	assert.Nil(b.Wait(ctx, func() bool { return !b.Connected(app.UserId) }))
+	assert.True(b.ClosedNormally(app.UserId))
+}
+
+func TestOAuthSession(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	app, user := api.CreateUser("app"), api.CreateUser("user")
+	client := api.Client(app)
+	client.SetBlazeUri(b.Host)
+	s, err := client.NewOAuthSession(app.UserId, "PROFILE:READ")
+	assert.Nil(err)
+	hc := &http.Client{Transport: &http.Transport{TLSClientConfig: b.Dialer().TLSClientConfig}}
+	s.SetDialOptions(&websocket.DialOptions{HTTPClient: hc})
+	s.Interval = 10 * time.Millisecond
+
+	_, err = s.Exchange(ctx, "secret")
+	assert.ErrorContains(err, "not authorized")
+
+	updates := s.Authorize(ctx)
+	pending := <-updates
+	assert.Equal(bot.OAuthStatusPending, pending.Status)
+	assert.Equal("mixin://codes/"+pending.CodeId, pending.URL)
+	assert.Nil(api.AuthorizeOAuth(pending.CodeId, user.UserId))
+	authorized := <-updates
+	assert.Equal(bot.OAuthStatusAuthorized, authorized.Status)
+	assert.Equal(pending.AuthorizationId, authorized.AuthorizationId)
+	_, ok := <-updates
+	assert.False(ok)
+
+	token, err := s.Exchange(ctx, "secret")
+	assert.Nil(err)
+	assert.Equal(pending.AuthorizationId, token.AuthorizationId)
+	assert.Equal("PROFILE:READ", token.Scope)
+	assert.Equal(app.ServerPublicKey, token.ServerPublicKey)
+
+	// the token signs the requests of the user
+	u, err := client.WithAuthorizer(token).GetUser(ctx, app.UserId)
+	assert.Nil(err)
+	assert.Equal(app.UserId, u.UserId)
+	signed, err := user.AccessToken("GET", "/users/"+app.UserId, "")
+	assert.Nil(err)
+	u, err = api.Client(user).WithAuthorizer(bot.BearerToken(signed)).GetUser(ctx, app.UserId)
+	assert.Nil(err)
+	assert.Equal(app.UserId, u.UserId)
+	_, err = client.WithAuthorizer(bot.BearerToken(signed)).GetUser(ctx, user.UserId)
+	assert.NotNil(err)
+
+	// the user id of the token is read from /safe/me
+	c, err := client.WithAuthorizer(token).CreateContactConversation(ctx, app.UserId)
+	assert.Nil(err)
+	assert.Equal(bot.UniqueConversationId(user.UserId, app.UserId), c.ConversationId)
+	assert.Equal(user.UserId, c.CreatorId)
+
+	// the code can only be exchanged once
+	_, err = s.Exchange(ctx, "secret")
+	assert.NotNil(err)
+}
@@ -0,0 +1,568 @@
+package bottest
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/gofrs/uuid/v5"
+)
+
+type output struct {
+	bot.Output
+	lockedBy string
+}
+
+// ghost remembers the owner of a ghost key issued by /safe/keys, and the
+// private view key which is returned to the owner when the key is spent.
+type ghost struct {
+	owner string
+	view  *crypto.Key
+}
+
+type request struct {
+	bot.SequencerTransactionRequest
+	inputs []*output
+}
+
+// Deposit credits amount of assetId to userId as a new unspent output, the
+// asset id is the uuid of the asset.
+func (s *Server) Deposit(userId, assetId, amount string) *bot.Output {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	amt := common.NewIntegerFromString(amount)
+	hash := crypto.Blake3Hash([]byte(bot.UuidNewV4().String()))
+	mask, keys := s.ghostKeys([]string{userId})
+	o := s.addOutput(hash, 0, assetId, amt, mask, keys, nil, 1, "", "")
+	return &o.Output
+}
+
+// Balance sums the unspent outputs of assetId owned by userId alone.
+func (s *Server) Balance(userId, assetId string) common.Integer {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	members := bot.HashMembers([]string{userId})
+	total := common.Zero
+	for _, o := range s.outputs {
+		if o.ReceiversHash == members && o.AssetId == assetId && o.State == bot.OutputStateUnspent {
+			total = total.Add(common.NewIntegerFromString(o.Amount))
+		}
+	}
+	return total
+}
+
+func (s *Server) addOutput(hash crypto.Hash, index uint, assetId string, amount common.Integer, mask crypto.Key, keys []*crypto.Key, senders []string, threshold uint8, extra, requestId string) *output {
+	receivers := make([]string, len(keys))
+	for i, k := range keys {
+		if g := s.ghosts[k.String()]; g != nil {
+			receivers[i] = g.owner
+		}
+	}
+	keyStrings := make([]string, len(keys))
+	for i, k := range keys {
+		keyStrings[i] = k.String()
+	}
+	var sendersThreshold int64
+	if len(senders) > 0 {
+		sendersThreshold = 1
+	}
+	s.sequence++
+	o := &output{Output: bot.Output{
+		Type:               "kernel_output",
+		OutputID:           bot.UniqueObjectId(hash.String(), strconv.Itoa(int(index))),
+		TransactionHash:    hash.String(),
+		OutputIndex:        index,
+		AssetId:            assetId,
+		KernelAssetId:      kernelAssetId(assetId),
+		Amount:             amount.String(),
+		Mask:               mask.String(),
+		Keys:               keyStrings,
+		Senders:            senders,
+		SendersHash:        hashMembers(senders),
+		SendersThreshold:   sendersThreshold,
+		Receivers:          receivers,
+		ReceiversHash:      hashMembers(receivers),
+		ReceiversThreshold: int64(threshold),
+		Extra:              extra,
+		State:              bot.OutputStateUnspent,
+		Sequence:           s.sequence,
+		CreatedAt:          s.timestamp(),
+		RequestId:          requestId,
+	}}
+	s.outputs = append(s.outputs, o)
+	return o
+}
+
+// ghostKeys issues one key for each receiver. The key of a user created by
+// CreateUser is the public key of view + spend, so that its outputs can only
+// be spent by the spend key of the user.
+func (s *Server) ghostKeys(receivers []string) (crypto.Key, []*crypto.Key) {
+	mask := randomKey().Public()
+	keys := make([]*crypto.Key, len(receivers))
+	for i, r := range receivers {
+		view := randomKey()
+		key := view.Public()
+		if u := s.users[r]; u != nil && u.spend != nil {
+			x, _ := edwards25519.NewScalar().SetCanonicalBytes(view[:])
+			var sum crypto.Key
+			copy(sum[:], edwards25519.NewScalar().Add(x, spendScalar(u.spend)).Bytes())
+			key = sum.Public()
+		}
+		s.ghosts[key.String()] = &ghost{owner: r, view: &view}
+		keys[i] = &key
+	}
+	return mask, keys
+}
+
+func (s *Server) listOutputs(r *http.Request, uid string, body []byte) (any, error) {
+	q := r.URL.Query()
+	offset, _ := strconv.ParseInt(q.Get("offset"), 10, 64)
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	outputs := []*bot.Output{}
+	for _, o := range s.outputs {
+		if len(outputs) == limit {
+			break
+		}
+		switch {
+		case !slices.Contains(o.Receivers, uid):
+		case o.ReceiversHash != q.Get("members"):
+		case q.Get("threshold") != strconv.FormatInt(o.ReceiversThreshold, 10):
+		case q.Get("asset") != "" && q.Get("asset") != o.AssetId && q.Get("asset") != o.KernelAssetId:
+		case q.Get("state") != "" && q.Get("state") != o.State:
+		case o.Sequence <= offset:
+		default:
+			outputs = append(outputs, &o.Output)
+		}
+	}
+	return outputs, nil
+}
+
+func (s *Server) createGhostKeys(r *http.Request, uid string, body []byte) (any, error) {
+	var gkr []*bot.GhostKeyRequest
+	if err := json.Unmarshal(body, &gkr); err != nil {
+		return nil, badData("invalid ghost key requests %v", err)
+	}
+	keys := make([]*bot.GhostKeys, len(gkr))
+	for i, g := range gkr {
+		if len(g.Receivers) == 0 {
+			return nil, badData("empty receivers %d", i)
+		}
+		mask, ks := s.ghostKeys(g.Receivers)
+		gk := &bot.GhostKeys{Type: "ghost_key", Mask: mask.String()}
+		for _, k := range ks {
+			gk.Keys = append(gk.Keys, k.String())
+		}
+		keys[i] = gk
+	}
+	return keys, nil
+}
+
+// verifyTransactions locks the inputs of each raw transaction to its request
+// id and returns the private view keys needed to sign them.
+func (s *Server) verifyTransactions(r *http.Request, uid string, body []byte) (any, error) {
+	var krs []*bot.KernelTransactionRequestCreateRequest
+	if err := json.Unmarshal(body, &krs); err != nil {
+		return nil, badData("invalid transaction requests %v", err)
+	}
+	var requests []*bot.SequencerTransactionRequest
+	for _, kr := range krs {
+		if req := s.requests[kr.RequestID]; req != nil {
+			requests = append(requests, &req.SequencerTransactionRequest)
+			continue
+		}
+		ver, err := decodeTransaction(kr.Raw)
+		if err != nil {
+			return nil, err
+		}
+		req := &request{SequencerTransactionRequest: bot.SequencerTransactionRequest{
+			RequestID:        kr.RequestID,
+			TransactionHash:  ver.PayloadHash().String(),
+			Asset:            ver.Asset.String(),
+			Extra:            hex.EncodeToString(ver.Extra),
+			Senders:          []string{uid},
+			SendersHash:      bot.HashMembers([]string{uid}),
+			SendersThreshold: 1,
+			State:            bot.OutputStateUnspent,
+			RawTransaction:   kr.Raw,
+			CreatedAt:        s.timestamp(),
+		}}
+		total := common.Zero
+		for _, in := range ver.Inputs {
+			o := s.findOutput(in.Hash, in.Index)
+			if o == nil || o.State != bot.OutputStateUnspent || !slices.Equal(o.Receivers, []string{uid}) {
+				return nil, badData("invalid input %s:%d", in.Hash, in.Index)
+			}
+			if o.KernelAssetId != ver.Asset.String() {
+				return nil, badData("invalid input asset %s", o.KernelAssetId)
+			}
+			if o.lockedBy != "" && o.lockedBy != kr.RequestID {
+				return nil, badData("input %s locked by other transaction %s", o.OutputID, o.lockedBy)
+			}
+			g := s.ghosts[o.Keys[0]]
+			req.Views = append(req.Views, g.view.String())
+			req.inputs = append(req.inputs, o)
+			total = total.Add(common.NewIntegerFromString(o.Amount))
+		}
+		spent := common.Zero
+		for i, out := range ver.Outputs {
+			spent = spent.Add(out.Amount)
+			if out.Withdrawal != nil {
+				req.Receivers = append(req.Receivers, &bot.TransactionReceiver{
+					Destination: out.Withdrawal.Address,
+					Tag:         out.Withdrawal.Tag,
+				})
+				continue
+			}
+			if len(out.Script) == 0 {
+				return nil, badData("empty script of output %d", i)
+			}
+			members := make([]string, len(out.Keys))
+			for j, k := range out.Keys {
+				g := s.ghosts[k.String()]
+				if g == nil {
+					return nil, badData("unknown ghost key %d:%d", i, j)
+				}
+				members[j] = g.owner
+			}
+			req.Receivers = append(req.Receivers, &bot.TransactionReceiver{
+				Members:     members,
+				MembersHash: bot.HashMembers(slices.Clone(members)),
+				Threshold:   out.Script[len(out.Script)-1],
+			})
+		}
+		if total.Cmp(spent) != 0 {
+			return nil, badData("inputs %s and outputs %s mismatch", total, spent)
+		}
+		req.Amount = spent.String()
+		for _, o := range req.inputs {
+			o.lockedBy = kr.RequestID
+		}
+		s.requests[kr.RequestID] = req
+		requests = append(requests, &req.SequencerTransactionRequest)
+	}
+	return requests, nil
+}
+
+// sendTransactions checks the signatures of verified transactions, spends
+// their inputs and credits the new outputs and snapshots to the receivers.
+func (s *Server) sendTransactions(r *http.Request, uid string, body []byte) (any, error) {
+	var krs []*bot.KernelTransactionRequestCreateRequest
+	if err := json.Unmarshal(body, &krs); err != nil {
+		return nil, badData("invalid transaction requests %v", err)
+	}
+	var requests []*bot.SequencerTransactionRequest
+	for _, kr := range krs {
+		req := s.requests[kr.RequestID]
+		if req == nil || req.Senders[0] != uid {
+			return nil, bot.ErrNotFound
+		}
+		if req.State != bot.OutputStateUnspent {
+			requests = append(requests, &req.SequencerTransactionRequest)
+			continue
+		}
+		ver, err := decodeTransaction(kr.Raw)
+		if err != nil {
+			return nil, err
+		}
+		hash := ver.PayloadHash()
+		if hash.String() != req.TransactionHash {
+			return nil, badData("transaction %s does not match request %s", hash, req.TransactionHash)
+		}
+		if len(ver.SignaturesMap) != len(req.inputs) {
+			return nil, badData("invalid signatures count %d", len(ver.SignaturesMap))
+		}
+		for i, o := range req.inputs {
+			key, _ := crypto.KeyFromString(o.Keys[0])
+			sig := ver.SignaturesMap[i][0]
+			if sig == nil || !key.Verify(hash, *sig) {
+				return nil, badData("invalid signature of input %d", i)
+			}
+		}
+
+		for _, o := range req.inputs {
+			o.State = bot.OutputStateSpent
+			o.SignedBy = hash.String()
+		}
+		assetId := req.inputs[0].AssetId
+		paid := make(map[string]common.Integer)
+		var opponents []string
+		for i, out := range ver.Outputs {
+			if out.Withdrawal != nil {
+				continue
+			}
+			if len(out.Script) == 0 {
+				return nil, badData("empty script of output %d", i)
+			}
+			o := s.addOutput(hash, uint(i), assetId, out.Amount, out.Mask, out.Keys, []string{uid}, out.Script[len(out.Script)-1], req.Extra, req.RequestID)
+			if len(o.Receivers) != 1 || o.Receivers[0] == uid {
+				continue
+			}
+			receiver := o.Receivers[0]
+			if _, found := paid[receiver]; !found {
+				paid[receiver] = common.Zero
+				opponents = append(opponents, receiver)
+			}
+			paid[receiver] = paid[receiver].Add(out.Amount)
+		}
+		total := common.Zero
+		for _, receiver := range opponents {
+			total = total.Add(paid[receiver])
+			s.addSnapshot(receiver, uid, assetId, paid[receiver].String(), hash, req)
+		}
+		if len(opponents) > 0 {
+			req.SnapshotID = s.addSnapshot(uid, opponents[0], assetId, "-"+total.String(), hash, req).SnapshotID
+		}
+		req.State = bot.OutputStateSpent
+		req.RawTransaction = kr.Raw
+		req.SnapshotHash = hash.String()
+		req.SnapshotAt = s.timestamp()
+		req.UpdatedAt = req.SnapshotAt
+		requests = append(requests, &req.SequencerTransactionRequest)
+	}
+	return requests, nil
+}
+
+func (s *Server) readTransaction(r *http.Request, uid string, body []byte) (any, error) {
+	req := s.requests[r.PathValue("id")]
+	if req == nil || req.Senders[0] != uid {
+		return nil, bot.ErrNotFound
+	}
+	return &req.SequencerTransactionRequest, nil
+}
+
+func (s *Server) addSnapshot(userId, opponentId, assetId, amount string, hash crypto.Hash, req *request) *bot.SafeSnapshot {
+	snapshot := &bot.SafeSnapshot{
+		Type:            "snapshot",
+		SnapshotID:      bot.UniqueObjectId(hash.String(), userId),
+		UserID:          userId,
+		OpponentID:      opponentId,
+		TransactionHash: hash.String(),
+		AssetID:         assetId,
+		KernelAssetID:   kernelAssetId(assetId),
+		Amount:          amount,
+		Memo:            req.Extra,
+		RequestId:       req.RequestID,
+		CreatedAt:       s.timestamp(),
+	}
+	s.snapshots = append(s.snapshots, snapshot)
+	return snapshot
+}
+
+// listSnapshots returns the snapshots of the user in ascending order, created
+// after the offset timestamp.
+func (s *Server) listSnapshots(r *http.Request, uid string, body []byte) (any, error) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	var offset time.Time
+	if q.Get("offset") != "" {
+		t, err := time.Parse(time.RFC3339Nano, q.Get("offset"))
+		if err != nil {
+			return nil, badData("invalid offset %s", q.Get("offset"))
+		}
+		offset = t
+	}
+	snapshots := []*bot.SafeSnapshot{}
+	for _, ss := range s.snapshots {
+		if len(snapshots) == limit {
+			break
+		}
+		switch {
+		case ss.UserID != uid:
+		case q.Get("asset") != "" && q.Get("asset") != ss.AssetID && q.Get("asset") != ss.KernelAssetID:
+		case q.Get("opponent") != "" && q.Get("opponent") != ss.OpponentID:
+		case !ss.CreatedAt.After(offset):
+		default:
+			snapshots = append(snapshots, ss)
+		}
+	}
+	return snapshots, nil
+}
+
+// listLegacySnapshots lists the snapshots of the user in the legacy format,
+// newest first unless the order is ASC, the offset is a created_at.
+func (s *Server) listLegacySnapshots(r *http.Request, uid string, body []byte) (any, error) {
+	snapshots := []*bot.LegacySnapshot{}
+	err := s.pageSnapshots(r, uid, func(ss *bot.SafeSnapshot) {
+		snapshots = append(snapshots, &bot.LegacySnapshot{
+			Type:            "snapshot",
+			SnapshotId:      ss.SnapshotID,
+			AssetId:         ss.AssetID,
+			Amount:          ss.Amount,
+			TransactionHash: ss.TransactionHash,
+			CreatedAt:       ss.CreatedAt,
+			OpponentId:      ss.OpponentID,
+			Memo:            ss.Memo,
+		})
+	})
+	return snapshots, err
+}
+
+// listNetworkSnapshots lists the snapshots of all users, like
+// listLegacySnapshots.
+func (s *Server) listNetworkSnapshots(r *http.Request, uid string, body []byte) (any, error) {
+	snapshots := []*bot.LegacySnapshotShort{}
+	err := s.pageSnapshots(r, "", func(ss *bot.SafeSnapshot) {
+		short := &bot.LegacySnapshotShort{
+			Type:       "snapshot",
+			SnapshotId: ss.SnapshotID,
+			Amount:     ss.Amount,
+			CreatedAt:  ss.CreatedAt,
+		}
+		short.Asset.AssetId = ss.AssetID
+		snapshots = append(snapshots, short)
+	})
+	return snapshots, err
+}
+
+func (s *Server) pageSnapshots(r *http.Request, uid string, add func(*bot.SafeSnapshot)) error {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	var offset time.Time
+	if q.Get("offset") != "" {
+		t, err := time.Parse(time.RFC3339Nano, q.Get("offset"))
+		if err != nil {
+			return badData("invalid offset %s", q.Get("offset"))
+		}
+		offset = t
+	}
+	asc := q.Get("order") == "ASC"
+	snapshots := slices.Clone(s.snapshots)
+	if !asc {
+		slices.Reverse(snapshots)
+	}
+	count := 0
+	for _, ss := range snapshots {
+		if count == limit {
+			break
+		}
+		switch {
+		case uid != "" && ss.UserID != uid:
+		case q.Get("asset") != "" && q.Get("asset") != ss.AssetID:
+		case !offset.IsZero() && asc && !ss.CreatedAt.After(offset):
+		case !offset.IsZero() && !asc && !ss.CreatedAt.Before(offset):
+		default:
+			add(ss)
+			count++
+		}
+	}
+	return nil
+}
+
+// listMultisigs lists the outputs of the members as multisig UTXOs, the
+// offset is an updated_at, which is the creation of the output here.
+func (s *Server) listMultisigs(r *http.Request, uid string, body []byte) (any, error) {
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	if limit <= 0 || limit > 500 {
+		limit = 500
+	}
+	var offset time.Time
+	if q.Get("offset") != "" {
+		t, err := time.Parse(time.RFC3339Nano, q.Get("offset"))
+		if err != nil {
+			return nil, badData("invalid offset %s", q.Get("offset"))
+		}
+		offset = t
+	}
+	utxos := []*bot.MultisigUTXO{}
+	for _, o := range s.outputs {
+		if len(utxos) == limit {
+			break
+		}
+		switch {
+		case !slices.Contains(o.Receivers, uid):
+		case o.ReceiversHash != q.Get("members"):
+		case q.Get("threshold") != strconv.FormatInt(o.ReceiversThreshold, 10):
+		case q.Get("state") != "" && q.Get("state") != o.State:
+		case !o.CreatedAt.After(offset):
+		default:
+			utxos = append(utxos, &bot.MultisigUTXO{
+				Type:            "multisig_utxo",
+				UserId:          uid,
+				UTXOId:          o.OutputID,
+				AssetId:         o.AssetId,
+				TransactionHash: o.TransactionHash,
+				OutputIndex:     int64(o.OutputIndex),
+				Amount:          o.Amount,
+				Threshold:       o.ReceiversThreshold,
+				Members:         o.Receivers,
+				State:           o.State,
+				CreatedAt:       o.CreatedAt,
+				UpdatedAt:       o.CreatedAt,
+			})
+		}
+	}
+	return utxos, nil
+}
+
+func (s *Server) findOutput(hash crypto.Hash, index uint) *output {
+	for _, o := range s.outputs {
+		if o.TransactionHash == hash.String() && o.OutputIndex == index {
+			return o
+		}
+	}
+	return nil
+}
+
+func decodeTransaction(raw string) (*common.VersionedTransaction, error) {
+	b, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, badData("invalid raw transaction %v", err)
+	}
+	ver, err := common.UnmarshalVersionedTransaction(b)
+	if err != nil {
+		return nil, badData("invalid raw transaction %v", err)
+	}
+	return ver, nil
+}
+
+func kernelAssetId(assetId string) string {
+	if uuid.FromStringOrNil(assetId).String() == assetId {
+		return crypto.Sha256Hash([]byte(assetId)).String()
+	}
+	return assetId
+}
+
+func hashMembers(members []string) string {
+	if len(members) == 0 {
+		return ""
+	}
+	return bot.HashMembers(slices.Clone(members))
+}
+
+func randomKey() crypto.Key {
+	seed := make([]byte, 64)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+	return crypto.NewKeyFromSeed(seed)
+}
+
+// spendScalar derives the signing scalar from a spend key the same way as
+// the transaction signer of the bot package.
+func spendScalar(spend *crypto.Key) *edwards25519.Scalar {
+	h := sha512.Sum512(spend[:])
+	y, err := edwards25519.NewScalar().SetBytesWithClamping(h[:32])
+	if err != nil {
+		panic(err)
+	}
+	return y
+}
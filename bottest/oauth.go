@@ -0,0 +1,161 @@
+package bottest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/gorilla/websocket"
+)
+
+const oauthSubprotocol = "Mixin-OAuth-1"
+
+type oauthAuthorization struct {
+	bot.OAuthAuthorization
+	clientId  string
+	scope     string
+	challenge string
+	userId    string
+	key       ed25519.PublicKey
+}
+
+// AuthorizeOAuth authorizes the code for the user, as if the user scanned it
+// in Messenger. The next refresh of the code returns the authorization code.
+func (s *Server) AuthorizeOAuth(codeId, userId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	for _, a := range s.oauth {
+		if a.CodeId != codeId {
+			continue
+		}
+		if s.users[userId] == nil {
+			return bot.ErrNotFound
+		}
+		code := make([]byte, 32)
+		rand.Read(code)
+		a.userId = userId
+		a.AuthorizationCode = hex.EncodeToString(code)
+		return nil
+	}
+	return bot.ErrNotFound
+}
+
+// refreshOAuthCode answers REFRESH_OAUTH_CODE, a new authorization is created
+// unless the params have a known authorization_id.
+func (s *Server) refreshOAuthCode(params map[string]any) (*bot.OAuthAuthorization, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	clientId, _ := params["client_id"].(string)
+	scope, _ := params["scope"].(string)
+	challenge, _ := params["code_challenge"].(string)
+	if s.users[clientId] == nil || challenge == "" {
+		return nil, bot.ErrBadData
+	}
+	id, _ := params["authorization_id"].(string)
+	a := s.oauth[id]
+	if a == nil || a.clientId != clientId || a.challenge != challenge {
+		a = &oauthAuthorization{
+			OAuthAuthorization: bot.OAuthAuthorization{
+				AuthorizationId: bot.UuidNewV4().String(),
+				CodeId:          bot.UuidNewV4().String(),
+				CreatedAt:       s.timestamp(),
+			},
+			clientId:  clientId,
+			scope:     scope,
+			challenge: challenge,
+		}
+		s.oauth[a.AuthorizationId] = a
+	}
+	view := a.OAuthAuthorization
+	return &view, nil
+}
+
+// oauthToken exchanges an authorization code, it's not authenticated.
+func (s *Server) oauthToken(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		s.render(w, r, nil, bot.ErrBadData)
+		return
+	}
+	var req struct {
+		ClientId     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+		Ed25519      string `json:"ed25519"`
+	}
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.render(w, r, nil, bot.ErrBadData)
+		return
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	var a *oauthAuthorization
+	for _, o := range s.oauth {
+		if o.AuthorizationCode != "" && o.AuthorizationCode == req.Code && o.clientId == req.ClientId {
+			a = o
+		}
+	}
+	sum := sha256.Sum256([]byte(req.CodeVerifier))
+	if a == nil || a.key != nil || base64.RawURLEncoding.EncodeToString(sum[:]) != a.challenge {
+		s.render(w, r, nil, bot.ErrForbidden)
+		return
+	}
+	key, err := base64.RawURLEncoding.DecodeString(req.Ed25519)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		s.render(w, r, nil, bot.ErrBadData)
+		return
+	}
+	a.key = key
+	pub, _ := hex.DecodeString(s.serverPub)
+	s.render(w, r, map[string]any{
+		"scope":            a.scope,
+		"authorization_id": a.AuthorizationId,
+		"ed25519":          base64.RawURLEncoding.EncodeToString(pub),
+	}, nil)
+}
+
+// serveOAuth serves the Mixin-OAuth-1 websocket, which needs no
+// authentication and only answers REFRESH_OAUTH_CODE.
+func (b *BlazeServer) serveOAuth(w http.ResponseWriter, r *http.Request) {
+	if b.api == nil {
+		http.Error(w, "no api server", http.StatusNotFound)
+		return
+	}
+	upgrader := websocket.Upgrader{Subprotocols: []string{oauthSubprotocol}}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	c := &blazeConn{conn: conn}
+	for {
+		typ, data, err := conn.ReadMessage()
+		if err != nil || typ != websocket.BinaryMessage {
+			return
+		}
+		msg, err := decodeBlazeMessage(data)
+		if err != nil {
+			return
+		}
+		reply := &bot.BlazeMessage{Id: msg.Id, Action: msg.Action}
+		if msg.Action != "REFRESH_OAUTH_CODE" {
+			e := bot.ErrBadData
+			reply.Error = &e
+		} else if a, err := b.api.refreshOAuthCode(msg.Params); err != nil {
+			e, _ := bot.AsError(err)
+			reply.Error = &e
+		} else {
+			reply.Data, _ = json.Marshal(a)
+		}
+		if c.write(reply) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,311 @@
+// Package bottest runs a fake Mixin API on an httptest.Server, so that code
+// using the bot package can be tested end to end without network.
+//
+// The server keeps users, UTXOs, snapshots, conversations and messages in
+// memory. Every request must carry a JWT signed by the session key of a known
+// user with a valid sig claim.
+package bottest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+type Server struct {
+	URL string
+
+	server    *httptest.Server
+	serverPub string
+
+	mutex         sync.Mutex
+	now           time.Time
+	users         map[string]*user
+	identity      int64
+	outputs       []*output
+	sequence      int64
+	ghosts        map[string]*ghost
+	requests      map[string]*request
+	snapshots     []*bot.SafeSnapshot
+	conversations map[string]*bot.Conversation
+	messages      []*Message
+	attachments   map[string][]byte
+	oauth         map[string]*oauthAuthorization
+	acknowledged  func(userId string, ids []string)
+}
+
+type user struct {
+	view     *bot.User
+	sessions []*session
+	spend    *crypto.Key
+}
+
+type session struct {
+	id  string
+	key ed25519.PublicKey
+}
+
+// Message is a message posted to /messages with the id of its sender.
+type Message struct {
+	bot.MessageRequest
+	UserId    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func NewServer() *Server {
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+	s := &Server{
+		serverPub:     hex.EncodeToString(ed25519.NewKeyFromSeed(seed).Public().(ed25519.PublicKey)),
+		users:         make(map[string]*user),
+		identity:      7000100000,
+		ghosts:        make(map[string]*ghost),
+		requests:      make(map[string]*request),
+		conversations: make(map[string]*bot.Conversation),
+		attachments:   make(map[string][]byte),
+		oauth:         make(map[string]*oauthAuthorization),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /safe/outputs", s.handle(s.listOutputs))
+	mux.HandleFunc("POST /safe/keys", s.handle(s.createGhostKeys))
+	mux.HandleFunc("POST /safe/transaction/requests", s.handle(s.verifyTransactions))
+	mux.HandleFunc("POST /safe/transactions", s.handle(s.sendTransactions))
+	mux.HandleFunc("GET /safe/transactions/{id}", s.handle(s.readTransaction))
+	mux.HandleFunc("GET /safe/snapshots", s.handle(s.listSnapshots))
+	mux.HandleFunc("GET /snapshots", s.handle(s.listLegacySnapshots))
+	mux.HandleFunc("GET /network/snapshots", s.handlePublic(s.listNetworkSnapshots))
+	mux.HandleFunc("GET /multisigs/outputs", s.handle(s.listMultisigs))
+	mux.HandleFunc("POST /users", s.handle(s.createUser))
+	mux.HandleFunc("POST /users/fetch", s.handle(s.fetchUsers))
+	mux.HandleFunc("GET /users/{id}", s.handle(s.readUser))
+	mux.HandleFunc("GET /safe/me", s.handle(s.readMe))
+	mux.HandleFunc("POST /sessions/fetch", s.handle(s.fetchSessions))
+	mux.HandleFunc("POST /conversations", s.handle(s.createConversation))
+	mux.HandleFunc("GET /conversations/{id}", s.handle(s.readConversation))
+	mux.HandleFunc("POST /messages", s.handle(s.postMessages))
+	mux.HandleFunc("POST /acknowledgements", s.handle(s.postAcknowledgements))
+	mux.HandleFunc("POST /attachments", s.handle(s.createAttachment))
+	mux.HandleFunc("GET /attachments/{id}", s.handle(s.readAttachment))
+	mux.HandleFunc("PUT /blobs/{id}", s.serveBlob)
+	mux.HandleFunc("GET /blobs/{id}", s.serveBlob)
+	mux.HandleFunc("POST /oauth/token", s.oauthToken)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		s.render(w, r, nil, bot.ErrNotFound)
+	})
+	s.server = httptest.NewServer(mux)
+	s.URL = s.server.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Client returns a client of the server using su as the default identity.
+func (s *Server) Client(su *bot.SafeUser) *bot.Client {
+	c := bot.NewClient(su)
+	c.SetBaseUri(s.URL)
+	return c
+}
+
+// CreateUser registers a user with a session key and a spend key, the
+// returned SafeUser can sign requests and transactions for the server.
+func (s *Server) CreateUser(fullName string) *bot.SafeUser {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	spend := make([]byte, 32)
+	if _, err := rand.Read(spend); err != nil {
+		panic(err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u := s.addUser(fullName, "", pub)
+	key := crypto.Key(spend)
+	u.spend = &key
+	u.view.HasSafe = true
+	return &bot.SafeUser{
+		UserId:            u.view.UserId,
+		SessionId:         u.view.SessionId,
+		SessionPrivateKey: hex.EncodeToString(priv.Seed()),
+		ServerPublicKey:   s.serverPub,
+		SpendPrivateKey:   key.String(),
+	}
+}
+
+// AddSession adds a new session to the user of su, and returns a SafeUser
+// signing with the new session.
+func (s *Server) AddSession(su *bot.SafeUser) *bot.SafeUser {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	u := s.users[su.UserId]
+	ns := *su
+	ns.SessionId = bot.UuidNewV4().String()
+	ns.SessionPrivateKey = hex.EncodeToString(priv.Seed())
+	u.sessions = append(u.sessions, &session{id: ns.SessionId, key: pub})
+	return &ns
+}
+
+// Messages returns all messages posted so far in order.
+func (s *Server) Messages() []*Message {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	messages := make([]*Message, len(s.messages))
+	copy(messages, s.messages)
+	return messages
+}
+
+func (s *Server) addUser(fullName, appId string, sessionKey ed25519.PublicKey) *user {
+	s.identity++
+	u := &user{
+		view: &bot.User{
+			UserId:          bot.UuidNewV4().String(),
+			SessionId:       bot.UuidNewV4().String(),
+			IdentityNumber:  fmt.Sprint(s.identity),
+			FullName:        fullName,
+			AppId:           appId,
+			CreatedAt:       s.timestamp(),
+			ServerPublicKey: s.serverPub,
+		},
+	}
+	u.sessions = []*session{{id: u.view.SessionId, key: sessionKey}}
+	s.users[u.view.UserId] = u
+	return u
+}
+
+// timestamp returns a strictly increasing time, so that created_at offsets
+// paginate without gaps.
+func (s *Server) timestamp() time.Time {
+	now := time.Now().UTC()
+	if !now.After(s.now) {
+		now = s.now.Add(time.Microsecond)
+	}
+	s.now = now
+	return now
+}
+
+type handler func(r *http.Request, uid string, body []byte) (any, error)
+
+func (s *Server) handle(h handler) http.HandlerFunc {
+	return s.serve(h, false)
+}
+
+// handlePublic serves the endpoints open to anonymous requests, the uid is
+// empty unless the request is signed.
+func (s *Server) handlePublic(h handler) http.HandlerFunc {
+	return s.serve(h, true)
+}
+
+func (s *Server) serve(h handler, public bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			s.render(w, r, nil, bot.ErrBadData)
+			return
+		}
+		s.mutex.Lock()
+		defer s.mutex.Unlock()
+		var uid string
+		// the client sends an empty bearer token when it has no authorizer
+		header := strings.TrimSpace(r.Header.Get("Authorization"))
+		if !public || (header != "" && header != "Bearer") {
+			uid, err = s.authenticate(r, body)
+			if err != nil {
+				s.render(w, r, nil, bot.ErrUnauthorized)
+				return
+			}
+		}
+		data, err := h(r, uid, body)
+		s.render(w, r, data, err)
+	}
+}
+
+// authenticate checks the session or OAuth signature of the JWT and its sig
+// claim against the method, uri and body of the request.
+func (s *Server) authenticate(r *http.Request, body []byte) (string, error) {
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return "", fmt.Errorf("no authorization")
+	}
+	return s.verifyToken(header[7:], r.Method, r.URL.RequestURI(), body)
+}
+
+func (s *Server) verifyToken(token, method, uri string, body []byte) (string, error) {
+	var u *user
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		if aid, ok := claims["aid"].(string); ok {
+			a := s.oauth[aid]
+			if a == nil || a.key == nil {
+				return nil, fmt.Errorf("unknown authorization %s", aid)
+			}
+			u = s.users[a.userId]
+			return a.key, nil
+		}
+		uid, _ := claims["uid"].(string)
+		sid, _ := claims["sid"].(string)
+		u = s.users[uid]
+		if u == nil {
+			return nil, fmt.Errorf("unknown user %s", uid)
+		}
+		for _, ss := range u.sessions {
+			if ss.id == sid {
+				return ss.key, nil
+			}
+		}
+		return nil, fmt.Errorf("unknown session %s %s", uid, sid)
+	}, jwt.WithValidMethods([]string{"EdDSA"}), jwt.WithExpirationRequired())
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(method + uri + string(body)))
+	if claims["sig"] != hex.EncodeToString(sum[:]) {
+		return "", fmt.Errorf("invalid sig claim")
+	}
+	return u.view.UserId, nil
+}
+
+func (s *Server) render(w http.ResponseWriter, r *http.Request, data any, err error) {
+	var body []byte
+	if err != nil {
+		e, ok := bot.AsError(err)
+		if !ok {
+			e = bot.ErrInternalServer
+			e.Description = err.Error()
+		}
+		body, _ = json.Marshal(map[string]any{"error": e})
+	} else {
+		body, _ = json.Marshal(map[string]any{"data": data})
+	}
+	requestId := r.Header.Get("X-Request-Id")
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Request-Id", requestId)
+	w.Write(body)
+}
+
+func badData(format string, args ...any) error {
+	e := bot.ErrBadData
+	e.Description = fmt.Sprintf(format, args...)
+	return e
+}
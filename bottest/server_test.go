@@ -0,0 +1,309 @@
+package bottest
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"image"
+	"image/png"
+	"iter"
+	"strings"
+	"testing"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/MixinNetwork/mixin/crypto"
+	"github.com/stretchr/testify/assert"
+)
+
+const assetId = "965e5c6e-434c-3fa9-b780-c50f43cd955c"
+
+func TestTransfer(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	alice, bob := s.CreateUser("alice"), s.CreateUser("bob")
+	s.Deposit(alice.UserId, assetId, "4")
+	s.Deposit(alice.UserId, assetId, "6")
+	c := s.Client(alice)
+
+	trace := bot.UuidNewV4().String()
+	str, err := c.SendTransferTransaction(ctx, assetId, bob.UserId, "7.5", trace, []byte("memo"))
+	assert.Nil(err)
+	assert.Equal("spent", str.State)
+	assert.Equal("2.50000000", s.Balance(alice.UserId, assetId).String())
+	assert.Equal("7.50000000", s.Balance(bob.UserId, assetId).String())
+
+	str, err = c.GetTransactionById(ctx, trace)
+	assert.Nil(err)
+	assert.Equal("spent", str.State)
+	_, err = c.SendTransferTransaction(ctx, assetId, bob.UserId, "1", trace, nil)
+	assert.NotNil(err)
+
+	_, err = c.SendTransferTransaction(ctx, assetId, bob.UserId, "3", bot.UuidNewV4().String(), nil)
+	assert.ErrorIs(err, bot.ErrInsufficientBalance)
+
+	var amounts []string
+	for ss, err := range s.Client(bob).IterSafeSnapshots(ctx, "", assetId, "", 1) {
+		assert.Nil(err)
+		assert.Equal(alice.UserId, ss.OpponentID)
+		assert.Equal(hex.EncodeToString([]byte("memo")), ss.Memo)
+		amounts = append(amounts, ss.Amount)
+	}
+	assert.Equal([]string{"7.50000000"}, amounts)
+	snapshots, err := c.SafeSnapshots(ctx, 10, "", assetId, "", "")
+	assert.Nil(err)
+	assert.Len(snapshots, 1)
+	assert.Equal("-7.50000000", snapshots[0].Amount)
+
+	outputs, err := s.Client(bob).ListUnspentOutputs(ctx, bot.HashMembers([]string{bob.UserId}), 1, assetId)
+	assert.Nil(err)
+	assert.Len(outputs, 1)
+	outputs, err = c.ListUnspentOutputs(ctx, bot.HashMembers([]string{bob.UserId}), 1, assetId)
+	assert.Nil(err)
+	assert.Len(outputs, 0)
+
+	// an output without a script is rejected
+	outputs, err = c.ListUnspentOutputs(ctx, bot.HashMembers([]string{alice.UserId}), 1, assetId)
+	assert.Nil(err)
+	trace = bot.UuidNewV4().String()
+	recipients := []*bot.TransactionRecipient{{MixAddress: bot.NewUUIDMixAddress([]string{bob.UserId}, 1), Amount: "1"}}
+	tx, err := c.BuildRawTransaction(ctx, crypto.Sha256Hash([]byte(assetId)), outputs, recipients, nil, nil, trace)
+	assert.Nil(err)
+	tx.Outputs[0].Script = nil
+	raw := hex.EncodeToString(tx.AsVersioned().Marshal())
+	_, err = c.VerifyRawTransaction(ctx, []*bot.KernelTransactionRequestCreateRequest{{RequestID: trace, Raw: raw}})
+	assert.ErrorIs(err, bot.ErrBadData)
+}
+
+func TestIterators(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	alice, bob := s.CreateUser("alice"), s.CreateUser("bob")
+	for range 5 {
+		s.Deposit(alice.UserId, assetId, "1")
+	}
+	c := s.Client(alice)
+	for range 5 {
+		_, err := c.SendTransferTransaction(ctx, assetId, bob.UserId, "0.1", bot.UuidNewV4().String(), nil)
+		assert.Nil(err)
+	}
+
+	// each iterator pages by 2 past the limit boundary and matches one page
+	members := bot.HashMembers([]string{alice.UserId})
+	outputs, err := collect(c.IterOutputs(ctx, members, 1, assetId, "", 2))
+	assert.Nil(err)
+	page, err := c.ListOutputs(ctx, members, 1, assetId, "", 0, 500)
+	assert.Nil(err)
+	assert.Greater(len(outputs), 2)
+	assert.Equal(page, outputs)
+
+	safe, err := collect(s.Client(bob).IterSafeSnapshots(ctx, "", assetId, "", 2))
+	assert.Nil(err)
+	safePage, err := s.Client(bob).SafeSnapshots(ctx, 500, "", assetId, "", "")
+	assert.Nil(err)
+	assert.Len(safe, 5)
+	assert.Equal(safePage, safe)
+
+	for _, order := range []string{"ASC", "DESC"} {
+		legacy, err := collect(c.IterSnapshots(ctx, assetId, order, 2))
+		assert.Nil(err)
+		legacyPage, err := c.Snapshots(ctx, 500, "", assetId, order)
+		assert.Nil(err)
+		assert.Len(legacy, 5, order)
+		assert.Equal(legacyPage, legacy, order)
+
+		network, err := collect(c.WithoutAuthorizer().IterNetworkSnapshots(ctx, assetId, order, 3))
+		assert.Nil(err)
+		networkPage, err := c.WithoutAuthorizer().NetworkSnapshots(ctx, 500, "", assetId, order)
+		assert.Nil(err)
+		assert.Len(network, 10, order)
+		assert.Equal(networkPage, network, order)
+	}
+
+	multisigs, err := collect(c.IterMultisigs(ctx, members, "1", "unspent", 2))
+	assert.Nil(err)
+	multisigsPage, err := c.ReadMultisigs(ctx, 500, "", members, "1", "unspent")
+	assert.Nil(err)
+	assert.Len(multisigs, 5)
+	assert.Equal(multisigsPage, multisigs)
+}
+
+func collect[T any](seq iter.Seq2[T, error]) ([]T, error) {
+	var items []T
+	for item, err := range seq {
+		if err != nil {
+			return items, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func TestMessaging(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	app := s.CreateUser("app")
+	c := s.Client(app)
+
+	pub, _, _ := ed25519.GenerateKey(rand.Reader)
+	u, err := c.CreateUser(ctx, base64.RawURLEncoding.EncodeToString(pub), "network user")
+	assert.Nil(err)
+	assert.Equal(app.UserId, u.AppId)
+	fetched, err := c.GetUser(ctx, u.UserId)
+	assert.Nil(err)
+	assert.Equal("network user", fetched.FullName)
+
+	conv, err := c.CreateContactConversation(ctx, u.UserId)
+	assert.Nil(err)
+	assert.Len(conv.Participants, 2)
+	err = c.PostMessageRequest(ctx, &bot.MessageRequest{
+		ConversationId: conv.ConversationId,
+		RecipientId:    u.UserId,
+		MessageId:      bot.UuidNewV4().String(),
+		Category:       bot.MessageCategoryPlainText,
+		DataBase64:     base64.RawURLEncoding.EncodeToString([]byte("hello")),
+	})
+	assert.Nil(err)
+	messages := s.Messages()
+	assert.Len(messages, 1)
+	assert.Equal(app.UserId, messages[0].UserId)
+
+	forged := *app
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	forged.SessionPrivateKey = hex.EncodeToString(priv.Seed())
+	_, err = s.Client(&forged).GetUser(ctx, u.UserId)
+	assert.ErrorIs(err, bot.ErrUnauthorized)
+}
+
+func TestEncryptedMessaging(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	app, user := s.CreateUser("app"), s.CreateUser("user")
+	phone := s.AddSession(user)
+	c := s.Client(app)
+	c.SetEncryption(true)
+
+	send := func(text string) *Message {
+		err := c.PostMessageRequest(ctx, &bot.MessageRequest{
+			ConversationId: bot.UniqueConversationId(app.UserId, user.UserId),
+			RecipientId:    user.UserId,
+			MessageId:      bot.UuidNewV4().String(),
+			Category:       bot.MessageCategoryPlainText,
+			DataBase64:     base64.RawURLEncoding.EncodeToString([]byte(text)),
+		})
+		assert.Nil(err)
+		messages := s.Messages()
+		return messages[len(messages)-1]
+	}
+	decrypt := func(msg *Message, su *bot.SafeUser) string {
+		seed, _ := hex.DecodeString(su.SessionPrivateKey)
+		private := base64.RawURLEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed))
+		data, err := bot.DecryptMessageData(msg.DataBase64, su.SessionId, private)
+		assert.Nil(err)
+		plain, _ := base64.RawURLEncoding.DecodeString(data)
+		return string(plain)
+	}
+
+	msg := send("hello")
+	assert.Equal("ENCRYPTED_TEXT", msg.Category)
+	assert.Len(msg.RecipientSessions, 2)
+	assert.Equal("hello", decrypt(msg, user))
+	assert.Equal("hello", decrypt(msg, phone))
+
+	// the cached sessions miss the new session, the checksum is rejected and
+	// the message is sent again with the fresh sessions
+	desktop := s.AddSession(user)
+	msg = send("again")
+	assert.Len(msg.RecipientSessions, 3)
+	assert.Equal("again", decrypt(msg, desktop))
+	assert.Len(s.Messages(), 2)
+
+	// the group messages have no recipient and are sent in plain
+	group, err := c.CreateGroupConversation(ctx, "group", "", []bot.Participant{{UserId: user.UserId}})
+	assert.Nil(err)
+	err = c.PostMessageRequest(ctx, &bot.MessageRequest{
+		ConversationId: group.ConversationId,
+		MessageId:      bot.UuidNewV4().String(),
+		Category:       bot.MessageCategoryPlainText,
+		DataBase64:     base64.RawURLEncoding.EncodeToString([]byte("group")),
+	})
+	assert.Nil(err)
+	messages := s.Messages()
+	assert.Len(messages, 3)
+	assert.Equal(bot.MessageCategoryPlainText, messages[2].Category)
+	assert.Equal(group.ConversationId, messages[2].ConversationId)
+}
+
+func TestAttachments(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+	s := NewServer()
+	defer s.Close()
+
+	app, user := s.CreateUser("app"), s.CreateUser("user")
+	conversationId := bot.UniqueConversationId(app.UserId, user.UserId)
+	c := s.Client(app)
+
+	err := c.SendFile(ctx, conversationId, user.UserId, "notes.txt", "", strings.NewReader("plain notes"))
+	assert.Nil(err)
+	p := lastPayload(t, s)
+	assert.Equal(bot.MessageCategoryPlainData, p.Category)
+	assert.Equal("notes.txt", p.Data.Name)
+	assert.Equal("text/plain; charset=utf-8", p.Data.MimeType)
+	assert.Equal(int64(11), p.Data.Size)
+	assert.Nil(p.Data.Key)
+	data, err := s.Client(user).DownloadAttachment(ctx, p.Data.AttachmentId)
+	assert.Nil(err)
+	assert.Equal("plain notes", string(data))
+
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, 200, 100))
+	assert.Nil(png.Encode(&buf, img))
+	c.SetEncryption(true)
+	err = c.SendImage(ctx, conversationId, user.UserId, bytes.NewReader(buf.Bytes()))
+	assert.Nil(err)
+	messages := s.Messages()
+	assert.Equal("ENCRYPTED_IMAGE", messages[len(messages)-1].Category)
+
+	seed, _ := hex.DecodeString(user.SessionPrivateKey)
+	private := base64.RawURLEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed))
+	plain, err := bot.DecryptMessageData(messages[len(messages)-1].DataBase64, user.SessionId, private)
+	assert.Nil(err)
+	p, err = bot.MessageView{Category: bot.MessageCategoryPlainImage, DataBase64: plain}.Decode()
+	assert.Nil(err)
+	assert.Equal(200, p.Image.Width)
+	assert.Equal(100, p.Image.Height)
+	assert.Equal("image/png", p.Image.MimeType)
+	assert.Equal(int64(buf.Len()), p.Image.Size)
+	assert.NotEmpty(p.Image.Thumbnail)
+	blob, err := s.Client(user).DownloadAttachment(ctx, p.Image.AttachmentId)
+	assert.Nil(err)
+	assert.NotEqual(buf.Bytes(), blob)
+	data, err = bot.DecryptAttachment(blob, p.Image.Key, p.Image.Digest)
+	assert.Nil(err)
+	assert.Equal(buf.Bytes(), data)
+}
+
+func lastPayload(t *testing.T, s *Server) *bot.MessagePayload {
+	messages := s.Messages()
+	msg := messages[len(messages)-1]
+	p, err := bot.MessageView{MessageId: msg.MessageId, Category: msg.Category, DataBase64: msg.DataBase64}.Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
@@ -0,0 +1,147 @@
+package bottest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTelemetry(t *testing.T) {
+	assert := assert.New(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	api := NewServer()
+	defer api.Close()
+	b := NewBlazeServer(api)
+	defer b.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	reader := sdkmetric.NewManualReader()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+
+	alice, bob := api.CreateUser("alice"), api.CreateUser("bob")
+	api.Deposit(alice.UserId, assetId, "10")
+	c := api.Client(alice)
+	c.SetTelemetry(tp, mp)
+	c.SetBlazeUri(b.Host)
+
+	spans := func(name string) []sdktrace.ReadOnlySpan {
+		var found []sdktrace.ReadOnlySpan
+		for _, s := range recorder.Ended() {
+			if s.Name() == name {
+				found = append(found, s)
+			}
+		}
+		return found
+	}
+	attrs := func(s sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		m := make(map[attribute.Key]attribute.Value)
+		for _, kv := range s.Attributes() {
+			m[kv.Key] = kv.Value
+		}
+		return m
+	}
+
+	// a REST call, and one failing in the transport
+	_, err := c.GetUser(ctx, bob.UserId)
+	assert.Nil(err)
+	unreachable := api.Client(alice)
+	unreachable.SetTelemetry(tp, mp)
+	unreachable.SetBaseUri("http://127.0.0.1:1")
+	_, err = unreachable.GetUser(ctx, bob.UserId)
+	assert.NotNil(err)
+	requests := spans("GET /users")
+	assert.Len(requests, 2)
+	a := attrs(requests[0])
+	assert.Equal("GET", a["http.request.method"].AsString())
+	assert.Equal("/users/"+bob.UserId, a["url.path"].AsString())
+	assert.Equal(int64(1), a["mixin.attempts"].AsInt64())
+	assert.Equal(int64(200), a["http.response.status_code"].AsInt64())
+	assert.NotEmpty(a["mixin.request_id"].AsString())
+	assert.Equal(codes.Unset, requests[0].Status().Code)
+	assert.Equal(codes.Error, requests[1].Status().Code)
+
+	// a transaction has a span for each phase
+	trace := bot.UuidNewV4().String()
+	_, err = c.SendTransferTransaction(ctx, assetId, bob.UserId, "1", trace, nil)
+	assert.Nil(err)
+	transactions := spans("sendTransaction")
+	assert.Len(transactions, 1)
+	assert.Equal(trace, attrs(transactions[0])["mixin.trace_id"].AsString())
+	assert.Equal(codes.Unset, transactions[0].Status().Code)
+	parent := transactions[0].SpanContext().SpanID()
+	for _, name := range []string{"build", "verify", "sign", "submit"} {
+		phase := spans("sendTransaction." + name)
+		assert.Len(phase, 1, name)
+		assert.Equal(parent, phase[0].Parent().SpanID(), name)
+	}
+	ghost := spans("sendTransaction.ghost_keys")
+	assert.Len(ghost, 1)
+	assert.Equal(spans("sendTransaction.build")[0].SpanContext().SpanID(), ghost[0].Parent().SpanID())
+	assert.Len(spans("POST /safe/transaction"), 1)
+	assert.Len(spans("POST /safe/transactions"), 1)
+
+	// a Blaze action retried once after an error reply, and one failing
+	bc := c.NewBlazeClient()
+	bc.SetupDailer(b.Dialer())
+	go bc.Loop(ctx, &testListener{})
+	assert.Nil(b.Wait(ctx, func() bool { return b.Connected(alice.UserId) }))
+	msg := bot.MessageView{ConversationId: bot.UniqueConversationId(alice.UserId, bob.UserId), UserId: bob.UserId}
+	b.InjectError("CREATE_MESSAGE", bot.ErrorCodeInternalServer)
+	assert.Nil(bc.SendPlainText(ctx, msg, "hi"))
+	bc.SetActionRetryPolicy(nil)
+	b.InjectError("CREATE_MESSAGE", bot.ErrorCodeInternalServer)
+	assert.NotNil(bc.SendPlainText(ctx, msg, "hi"))
+	actions := spans("blaze CREATE_MESSAGE")
+	assert.Len(actions, 2)
+	a = attrs(actions[0])
+	assert.Equal("CREATE_MESSAGE", a["mixin.blaze.action"].AsString())
+	assert.Equal(int64(2), a["mixin.attempts"].AsInt64())
+	assert.Equal(int64(bot.ErrorCodeInternalServer), a["mixin.error.code"].AsInt64())
+	assert.Equal(codes.Unset, actions[0].Status().Code)
+	assert.Equal(int64(1), attrs(actions[1])["mixin.attempts"].AsInt64())
+	assert.Equal(codes.Error, actions[1].Status().Code)
+
+	var rm metricdata.ResourceMetrics
+	assert.Nil(reader.Collect(ctx, &rm))
+	metrics := make(map[string]metricdata.Aggregation)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	counts := func(name string, kv attribute.KeyValue) int64 {
+		var total int64
+		switch data := metrics[name].(type) {
+		case metricdata.Histogram[float64]:
+			for _, p := range data.DataPoints {
+				if v, ok := p.Attributes.Value(kv.Key); ok && v == kv.Value {
+					total += int64(p.Count)
+				}
+			}
+		case metricdata.Sum[int64]:
+			for _, p := range data.DataPoints {
+				if v, ok := p.Attributes.Value(kv.Key); ok && v == kv.Value {
+					total += p.Value
+				}
+			}
+		}
+		return total
+	}
+	assert.Equal(int64(2), counts("mixin.client.request.duration", attribute.String("mixin.endpoint", "/users")))
+	assert.Equal(int64(1), counts("mixin.client.request.errors", attribute.String("error.type", "transport")))
+	assert.Equal(int64(1), counts("mixin.client.request.duration", attribute.String("mixin.endpoint", "/safe/transactions")))
+	assert.Equal(int64(2), counts("mixin.blaze.action.duration", attribute.String("mixin.blaze.action", "CREATE_MESSAGE")))
+	assert.Equal(int64(1), counts("mixin.blaze.action.errors", attribute.Int("mixin.error.code", bot.ErrorCodeInternalServer)))
+}
@@ -0,0 +1,229 @@
+package bottest
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/MixinNetwork/bot-api-go-client/v3"
+)
+
+// createUser registers a network user of the requesting app, the session
+// secret is the base64 ed25519 public key of the new session.
+func (s *Server) createUser(r *http.Request, uid string, body []byte) (any, error) {
+	var params struct {
+		SessionSecret string `json:"session_secret"`
+		FullName      string `json:"full_name"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, badData("invalid user %v", err)
+	}
+	pub, err := base64.RawURLEncoding.DecodeString(params.SessionSecret)
+	if err != nil {
+		pub, err = base64.StdEncoding.DecodeString(params.SessionSecret)
+	}
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, badData("invalid session secret %s", params.SessionSecret)
+	}
+	if params.FullName == "" {
+		return nil, badData("empty full name")
+	}
+	u := s.addUser(params.FullName, uid, ed25519.PublicKey(pub))
+	return u.view, nil
+}
+
+func (s *Server) readUser(r *http.Request, uid string, body []byte) (any, error) {
+	u := s.users[r.PathValue("id")]
+	if u == nil {
+		return nil, bot.ErrNotFound
+	}
+	return u.view, nil
+}
+
+func (s *Server) readMe(r *http.Request, uid string, body []byte) (any, error) {
+	return &bot.UserMeView{User: *s.users[uid].view}, nil
+}
+
+func (s *Server) fetchUsers(r *http.Request, uid string, body []byte) (any, error) {
+	var ids []string
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, badData("invalid user ids %v", err)
+	}
+	users := []*bot.User{}
+	for _, id := range ids {
+		if u := s.users[id]; u != nil {
+			users = append(users, u.view)
+		}
+	}
+	return users, nil
+}
+
+// createConversation returns the existing conversation for the same id, like
+// the API does for contact conversations.
+func (s *Server) createConversation(r *http.Request, uid string, body []byte) (any, error) {
+	var params struct {
+		Category       string            `json:"category"`
+		ConversationId string            `json:"conversation_id"`
+		Name           string            `json:"name"`
+		Announcement   string            `json:"announcement"`
+		Participants   []bot.Participant `json:"participants"`
+	}
+	if err := json.Unmarshal(body, &params); err != nil {
+		return nil, badData("invalid conversation %v", err)
+	}
+	if c := s.conversations[params.ConversationId]; c != nil {
+		return c, nil
+	}
+	switch params.Category {
+	case "CONTACT":
+		if len(params.Participants) != 1 {
+			return nil, badData("invalid participants count %d", len(params.Participants))
+		}
+		id := bot.UniqueConversationId(uid, params.Participants[0].UserId)
+		if id != params.ConversationId {
+			return nil, badData("invalid conversation id %s", params.ConversationId)
+		}
+	case "GROUP":
+	default:
+		return nil, badData("invalid category %s", params.Category)
+	}
+
+	now := s.timestamp()
+	c := &bot.Conversation{
+		ConversationId: params.ConversationId,
+		CreatorId:      uid,
+		Category:       params.Category,
+		Name:           params.Name,
+		Announcement:   params.Announcement,
+		CreatedAt:      now,
+		Participants:   []bot.Participant{{UserId: uid, Role: "OWNER", CreatedAt: now}},
+	}
+	for _, p := range params.Participants {
+		if s.users[p.UserId] == nil {
+			return nil, badData("invalid participant %s", p.UserId)
+		}
+		if p.UserId == uid {
+			continue
+		}
+		c.Participants = append(c.Participants, bot.Participant{UserId: p.UserId, Role: p.Role, CreatedAt: now})
+	}
+	for _, p := range c.Participants {
+		for _, ss := range s.users[p.UserId].sessions {
+			c.ParticipantSessions = append(c.ParticipantSessions, bot.ParticipantSessionView{
+				Type:      "participant_session",
+				UserId:    p.UserId,
+				SessionId: ss.id,
+				PublicKey: base64.RawURLEncoding.EncodeToString(ss.key),
+			})
+		}
+	}
+	s.conversations[c.ConversationId] = c
+	return c, nil
+}
+
+// fetchSessions returns the curve25519 public keys of the sessions, which
+// are used to encrypt messages.
+func (s *Server) fetchSessions(r *http.Request, uid string, body []byte) (any, error) {
+	var ids []string
+	if err := json.Unmarshal(body, &ids); err != nil {
+		return nil, badData("invalid user ids %v", err)
+	}
+	sessions := []*bot.UserSession{}
+	for _, id := range ids {
+		u := s.users[id]
+		if u == nil {
+			continue
+		}
+		for _, ss := range u.sessions {
+			pub, err := bot.PublicKeyToCurve25519(ss.key)
+			if err != nil {
+				return nil, err
+			}
+			sessions = append(sessions, &bot.UserSession{
+				UserId:    id,
+				SessionId: ss.id,
+				PublicKey: base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	return sessions, nil
+}
+
+// checkMessage requires an encrypted message to have the checksum of all
+// sessions of its recipient.
+func (s *Server) checkMessage(msg *bot.MessageRequest) error {
+	if !strings.HasPrefix(msg.Category, "ENCRYPTED_") {
+		return nil
+	}
+	u := s.users[msg.RecipientId]
+	if u == nil {
+		return badData("invalid recipient %s", msg.RecipientId)
+	}
+	var sessions []*bot.Session
+	for _, ss := range u.sessions {
+		sessions = append(sessions, &bot.Session{UserID: msg.RecipientId, SessionID: ss.id})
+	}
+	if msg.Checksum != bot.GenerateUserChecksum(sessions) || len(msg.RecipientSessions) != len(sessions) {
+		return bot.ErrChecksumInvalid
+	}
+	return nil
+}
+
+func (s *Server) readConversation(r *http.Request, uid string, body []byte) (any, error) {
+	c := s.conversations[r.PathValue("id")]
+	if c == nil || !slices.ContainsFunc(c.Participants, func(p bot.Participant) bool { return p.UserId == uid }) {
+		return nil, bot.ErrNotFound
+	}
+	return c, nil
+}
+
+// postMessages accepts a single message or a batch, the conversation must
+// exist unless the message has a recipient id.
+func (s *Server) postMessages(r *http.Request, uid string, body []byte) (any, error) {
+	var batch []*bot.MessageRequest
+	if err := json.Unmarshal(body, &batch); err != nil {
+		var msg bot.MessageRequest
+		if err := json.Unmarshal(body, &msg); err != nil {
+			return nil, badData("invalid messages %v", err)
+		}
+		batch = []*bot.MessageRequest{&msg}
+	}
+	for _, msg := range batch {
+		if msg.MessageId == "" || msg.Category == "" {
+			return nil, badData("invalid message %s", msg.MessageId)
+		}
+		if msg.RecipientId == "" && s.conversations[msg.ConversationId] == nil {
+			return nil, badData("invalid conversation %s", msg.ConversationId)
+		}
+		if err := s.checkMessage(msg); err != nil {
+			return nil, err
+		}
+	}
+	for _, msg := range batch {
+		s.messages = append(s.messages, &Message{MessageRequest: *msg, UserId: uid, CreatedAt: s.timestamp()})
+	}
+	return []any{}, nil
+}
+
+// postAcknowledgements removes the messages from the pending messages of the
+// Blaze server using this server.
+func (s *Server) postAcknowledgements(r *http.Request, uid string, body []byte) (any, error) {
+	var acks []*bot.ReceiptAcknowledgementRequest
+	if err := json.Unmarshal(body, &acks); err != nil {
+		return nil, badData("invalid acknowledgements %v", err)
+	}
+	ids := make([]string, len(acks))
+	for i, a := range acks {
+		if a.MessageId == "" || (a.Status != bot.AckStatusRead && a.Status != bot.AckStatusDelivered) {
+			return nil, badData("invalid acknowledgement %s %s", a.MessageId, a.Status)
+		}
+		ids[i] = a.MessageId
+	}
+	if s.acknowledged != nil {
+		s.acknowledged(uid, ids)
+	}
+	return []any{}, nil
+}
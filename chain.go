@@ -83,7 +83,11 @@ type NetworkChain struct {
 }
 
 func ReadNetworkChainById(ctx context.Context, chainId string) (*NetworkChain, error) {
-	body, err := Request(ctx, "GET", "/network/chains/"+chainId, nil, "")
+	return defaultClient.ReadNetworkChainById(ctx, chainId)
+}
+
+func (c *Client) ReadNetworkChainById(ctx context.Context, chainId string) (*NetworkChain, error) {
+	body, err := c.Request(ctx, "GET", "/network/chains/"+chainId, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -102,7 +106,11 @@ func ReadNetworkChainById(ctx context.Context, chainId string) (*NetworkChain, e
 }
 
 func ReadNetworkChains(ctx context.Context) ([]*NetworkChain, error) {
-	body, err := Request(ctx, "GET", "/network/chains", nil, "")
+	return defaultClient.ReadNetworkChains(ctx)
+}
+
+func (c *Client) ReadNetworkChains(ctx context.Context) ([]*NetworkChain, error) {
+	body, err := c.Request(ctx, "GET", "/network/chains", nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -10,7 +10,12 @@ type Code interface {
 }
 
 func ReadCode[C Code](ctx context.Context, codeId string) (C, error) {
-	body, err := Request(ctx, "GET", "/codes/"+codeId, nil, "")
+	return ReadCodeWithClient[C](ctx, defaultClient, codeId)
+}
+
+// ReadCodeWithClient is the client variant of ReadCode, methods can not have type parameters.
+func ReadCodeWithClient[C Code](ctx context.Context, c *Client, codeId string) (C, error) {
+	body, err := c.Request(ctx, "GET", "/codes/"+codeId, nil, "")
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -29,5 +34,9 @@ func ReadCode[C Code](ctx context.Context, codeId string) (C, error) {
 }
 
 func ReadMultisigByCode(ctx context.Context, codeId string) (*MultisigRequest, error) {
-	return ReadCode[*MultisigRequest](ctx, codeId)
+	return defaultClient.ReadMultisigByCode(ctx, codeId)
+}
+
+func (c *Client) ReadMultisigByCode(ctx context.Context, codeId string) (*MultisigRequest, error) {
+	return ReadCodeWithClient[*MultisigRequest](ctx, c, codeId)
 }
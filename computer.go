@@ -243,11 +243,15 @@ func GetFeeOnXINBasedOnSOL(ctx context.Context, solAmount string) (*ComputerFeeR
 }
 
 func RegisterComputer(ctx context.Context, su *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(su).RegisterComputer(ctx)
+}
+
+func (c *Client) RegisterComputer(ctx context.Context) (*SequencerTransactionRequest, error) {
 	info, err := GetComputerInfo(ctx)
 	if err != nil {
 		return nil, err
 	}
-	mix := NewUUIDMixAddress([]string{su.UserId}, 1).String()
+	mix := NewUUIDMixAddress([]string{c.user.UserId}, 1).String()
 	memo := EncodeMtgExtra(info.Members.AppId, EncodeOperationMemo(OperationTypeAddUser, []byte(mix)))
 
 	trace := UniqueObjectId(mix, "computer_register")
@@ -257,7 +261,7 @@ func RegisterComputer(ctx context.Context, su *SafeUser) (*SequencerTransactionR
 			Amount:     info.Params.Operation.Price,
 		},
 	}
-	return SendTransaction(ctx, info.Params.Operation.Asset, rs, trace, []byte(memo), nil, su)
+	return c.SendTransaction(ctx, info.Params.Operation.Asset, rs, trace, []byte(memo), nil)
 }
 
 func ComputerUserIDToBytes(id string) ([]byte, error) {
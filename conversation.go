@@ -36,26 +36,42 @@ type Conversation struct {
 	ParticipantSessions []ParticipantSessionView `json:"participant_sessions"`
 }
 
-func CreateContactConversation(ctx context.Context, participantID string, user *SafeUser) (*Conversation, error) {
+func CreateContactConversation(ctx context.Context, participantID string, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).CreateContactConversation(ctx, participantID)
+}
+
+func (c *Client) CreateContactConversation(ctx context.Context, participantID string) (*Conversation, error) {
+	userId, err := c.userId(ctx)
+	if err != nil {
+		return nil, err
+	}
 	participants := []Participant{
 		{
 			UserId: participantID,
 		},
 	}
-	return createConversation(ctx, "CONTACT", UniqueConversationId(participantID, user.UserId), "", "", participants, "", user)
+	return c.createConversation(ctx, "CONTACT", UniqueConversationId(participantID, userId), "", "", participants, "")
+}
+
+func CreateGroupConversation(ctx context.Context, name, announcement string, participants []Participant, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).CreateGroupConversation(ctx, name, announcement, participants)
 }
 
-func CreateGroupConversation(ctx context.Context, name, announcement string, participants []Participant, user *SafeUser) (*Conversation, error) {
+func (c *Client) CreateGroupConversation(ctx context.Context, name, announcement string, participants []Participant) (*Conversation, error) {
 	pids := make([]string, len(participants))
 	for i, p := range participants {
 		pids[i] = p.UserId
 	}
+	userId, err := c.userId(ctx)
+	if err != nil {
+		return nil, err
+	}
 	randomId := uuid.Must(uuid.NewV4()).String()
-	conversationId := GroupConversationId(user.UserId, name, pids, randomId)
-	return createConversation(ctx, "GROUP", conversationId, name, announcement, participants, randomId, user)
+	conversationId := GroupConversationId(userId, name, pids, randomId)
+	return c.createConversation(ctx, "GROUP", conversationId, name, announcement, participants, randomId)
 }
 
-func createConversation(ctx context.Context, category, conversationId string, name, announcement string, participants []Participant, randomId string, user *SafeUser) (*Conversation, error) {
+func (c *Client) createConversation(ctx context.Context, category, conversationId, name, announcement string, participants []Participant, randomId string) (*Conversation, error) {
 	params, err := json.Marshal(map[string]any{
 		"category":        category,
 		"conversation_id": conversationId,
@@ -72,11 +88,11 @@ func createConversation(ctx context.Context, category, conversationId string, na
 			return nil, fmt.Errorf("bad participants members length %d", len(participants))
 		}
 	}
-	accessToken, err := SignAuthenticationToken("POST", "/conversations", string(params), user)
+	accessToken, err := c.sign("POST", "/conversations", string(params))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/conversations", params, accessToken)
+	body, err := c.Request(ctx, "POST", "/conversations", params, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -100,17 +116,17 @@ func createConversation(ctx context.Context, category, conversationId string, na
 	return &resp.Data, nil
 }
 
-func ConversationShow(ctx context.Context, conversationId string, user *SafeUser) (*Conversation, error) {
+func ConversationShow(ctx context.Context, conversationId string, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).ConversationShow(ctx, conversationId)
+}
+
+func (c *Client) ConversationShow(ctx context.Context, conversationId string) (*Conversation, error) {
 	path := "/conversations/" + conversationId
-	token, err := SignAuthenticationToken("GET", path, "", user)
+	token, err := c.sign("GET", path, "")
 	if err != nil {
 		return nil, err
 	}
-	return ConversationShowByToken(ctx, conversationId, token)
-}
-
-func ConversationShowByToken(ctx context.Context, conversationId string, accessToken string) (*Conversation, error) {
-	body, err := Request(ctx, "GET", "/conversations/"+conversationId, nil, accessToken)
+	body, err := c.Request(ctx, "GET", "/conversations/"+conversationId, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -134,14 +150,23 @@ func ConversationShowByToken(ctx context.Context, conversationId string, accessT
 	return &resp.Data, nil
 }
 
-func JoinConversation(ctx context.Context, conversationId string, user *SafeUser) (*Conversation, error) {
+// Deprecated: use ConversationShow with BearerToken(accessToken).
+func ConversationShowByToken(ctx context.Context, conversationId string, accessToken string) (*Conversation, error) {
+	return ConversationShow(ctx, conversationId, BearerToken(accessToken))
+}
+
+func JoinConversation(ctx context.Context, conversationId string, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).JoinConversation(ctx, conversationId)
+}
+
+func (c *Client) JoinConversation(ctx context.Context, conversationId string) (*Conversation, error) {
 	path := fmt.Sprintf("/conversations/%s/join", conversationId)
-	accessToken, err := SignAuthenticationToken("POST", path, "", user)
+	accessToken, err := c.sign("POST", path, "")
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := Request(ctx, "POST", path, nil, accessToken)
+	body, err := c.Request(ctx, "POST", path, nil, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -163,14 +188,18 @@ func JoinConversation(ctx context.Context, conversationId string, user *SafeUser
 	return &resp.Data, nil
 }
 
-func RotateConversation(ctx context.Context, conversationId string, user *SafeUser) (*Conversation, error) {
+func RotateConversation(ctx context.Context, conversationId string, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).RotateConversation(ctx, conversationId)
+}
+
+func (c *Client) RotateConversation(ctx context.Context, conversationId string) (*Conversation, error) {
 	path := fmt.Sprintf("/conversations/%s/rotate", conversationId)
-	accessToken, err := SignAuthenticationToken("POST", path, "", user)
+	accessToken, err := c.sign("POST", path, "")
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := Request(ctx, "POST", path, nil, accessToken)
+	body, err := c.Request(ctx, "POST", path, nil, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -192,18 +221,22 @@ func RotateConversation(ctx context.Context, conversationId string, user *SafeUs
 	return &resp.Data, nil
 }
 
-func UpdateParticipants(ctx context.Context, conversationId, action string, requests []Participant, user *SafeUser) (*Conversation, error) {
+func UpdateParticipants(ctx context.Context, conversationId, action string, requests []Participant, auth Authorizer) (*Conversation, error) {
+	return defaultClient.WithAuthorizer(auth).UpdateParticipants(ctx, conversationId, action, requests)
+}
+
+func (c *Client) UpdateParticipants(ctx context.Context, conversationId, action string, requests []Participant) (*Conversation, error) {
 	path := fmt.Sprintf("/conversations/%s/participants/%s", conversationId, action)
 	params, err := json.Marshal(requests)
 	if err != nil {
 		return nil, err
 	}
-	accessToken, err := SignAuthenticationToken("POST", path, string(params), user)
+	accessToken, err := c.sign("POST", path, string(params))
 	if err != nil {
 		return nil, err
 	}
 
-	body, err := Request(ctx, "POST", path, params, accessToken)
+	body, err := c.Request(ctx, "POST", path, params, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,230 @@
+package bot
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DedupStore remembers the messages handled successfully, Blaze delivers a
+// message at least once, so a message may be listed again after reconnecting
+// even when it has been handled.
+type DedupStore interface {
+	Seen(ctx context.Context, messageId string) (bool, error)
+	Record(ctx context.Context, messageId string) error
+}
+
+// SetDedupStore makes Loop skip the messages seen by store, they are still
+// acknowledged. A message is recorded only after OnMessage returns nil.
+func (b *BlazeClient) SetDedupStore(store DedupStore) {
+	b.dedup = store
+}
+
+// MemoryDedupStore keeps the last size message ids for ttl, a zero ttl keeps
+// them until evicted by newer ones, and a zero size doesn't limit them.
+type MemoryDedupStore struct {
+	mutex sync.Mutex
+	size  int
+	ttl   time.Duration
+	order *list.List
+	ids   map[string]*list.Element
+}
+
+type dedupEntry struct {
+	id       string
+	expireAt time.Time
+}
+
+func NewMemoryDedupStore(size int, ttl time.Duration) *MemoryDedupStore {
+	return &MemoryDedupStore{size: size, ttl: ttl, order: list.New(), ids: make(map[string]*list.Element)}
+}
+
+func (s *MemoryDedupStore) Seen(ctx context.Context, messageId string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	e, ok := s.ids[messageId]
+	if !ok {
+		return false, nil
+	}
+	if expired(e.Value.(*dedupEntry).expireAt) {
+		s.order.Remove(e)
+		delete(s.ids, messageId)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryDedupStore) Record(ctx context.Context, messageId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	expireAt := expiration(s.ttl)
+	if e, ok := s.ids[messageId]; ok {
+		e.Value.(*dedupEntry).expireAt = expireAt
+		s.order.MoveToFront(e)
+		return nil
+	}
+	s.ids[messageId] = s.order.PushFront(&dedupEntry{id: messageId, expireAt: expireAt})
+	for s.size > 0 && s.order.Len() > s.size || s.order.Len() > 0 && expired(s.order.Back().Value.(*dedupEntry).expireAt) {
+		e := s.order.Back()
+		s.order.Remove(e)
+		delete(s.ids, e.Value.(*dedupEntry).id)
+	}
+	return nil
+}
+
+// FileDedupStore keeps the message ids for ttl in an append only file, so
+// that they survive a restart. The expired ids are dropped when the file is
+// compacted, which bounds the file and the ids in memory to the messages of
+// about one ttl.
+type FileDedupStore struct {
+	mutex   sync.Mutex
+	path    string
+	ttl     time.Duration
+	file    *os.File
+	ids     map[string]time.Time
+	appends int
+}
+
+// OpenFileDedupStore loads the ids from path, and creates it when missing.
+// The ttl must be positive, the store would grow forever otherwise.
+func OpenFileDedupStore(path string, ttl time.Duration) (*FileDedupStore, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("dedup store %s without ttl", path)
+	}
+	s := &FileDedupStore{path: path, ttl: ttl, ids: make(map[string]time.Time)}
+	f, err := os.Open(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			id, at, ok := strings.Cut(scanner.Text(), " ")
+			if !ok {
+				continue
+			}
+			nano, err := strconv.ParseInt(at, 10, 64)
+			if err != nil {
+				continue
+			}
+			// the ids of a store without ttl expire from now on
+			expireAt := expiration(ttl)
+			if nano > 0 {
+				expireAt = time.Unix(0, nano)
+			}
+			s.ids[id] = expireAt
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *FileDedupStore) Seen(ctx context.Context, messageId string) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	expireAt, ok := s.ids[messageId]
+	return ok && !expired(expireAt), nil
+}
+
+// Record appends the id to the file and syncs it before returning.
+func (s *FileDedupStore) Record(ctx context.Context, messageId string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return fmt.Errorf("dedup store %s is closed", s.path)
+	}
+	expireAt := expiration(s.ttl)
+	if _, err := fmt.Fprintf(s.file, "%s %d\n", messageId, expirationNano(expireAt)); err != nil {
+		return err
+	}
+	if err := s.file.Sync(); err != nil {
+		return err
+	}
+	s.ids[messageId] = expireAt
+	s.appends++
+	if s.appends > len(s.ids)+1024 {
+		return s.compact()
+	}
+	return nil
+}
+
+func (s *FileDedupStore) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.file == nil {
+		return nil
+	}
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// compact rewrites the file with the ids not expired, and reopens it to
+// append.
+func (s *FileDedupStore) compact() error {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	w := bufio.NewWriter(tmp)
+	for id, expireAt := range s.ids {
+		if expired(expireAt) {
+			delete(s.ids, id)
+			continue
+		}
+		fmt.Fprintf(w, "%s %d\n", id, expirationNano(expireAt))
+	}
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+	if s.file != nil {
+		s.file.Close()
+	}
+	s.file, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	s.appends = 0
+	return err
+}
+
+// expiration returns the zero time for a zero ttl, which never expires.
+func expiration(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+	return time.Now().Add(ttl)
+}
+
+func expirationNano(expireAt time.Time) int64 {
+	if expireAt.IsZero() {
+		return 0
+	}
+	return expireAt.UnixNano()
+}
+
+func expired(expireAt time.Time) bool {
+	return !expireAt.IsZero() && time.Now().After(expireAt)
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupStore(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	m := NewMemoryDedupStore(2, 50*time.Millisecond)
+	seen, err := m.Seen(ctx, "a")
+	assert.Nil(err)
+	assert.False(seen)
+	assert.Nil(m.Record(ctx, "a"))
+	assert.Nil(m.Record(ctx, "b"))
+	seen, _ = m.Seen(ctx, "a")
+	assert.True(seen)
+	assert.Nil(m.Record(ctx, "c"))
+	seen, _ = m.Seen(ctx, "a")
+	assert.False(seen)
+	time.Sleep(60 * time.Millisecond)
+	seen, _ = m.Seen(ctx, "c")
+	assert.False(seen)
+
+	path := filepath.Join(t.TempDir(), "dedup")
+	_, err = OpenFileDedupStore(path, 0)
+	assert.ErrorContains(err, "without ttl")
+	f, err := OpenFileDedupStore(path, time.Hour)
+	assert.Nil(err)
+	assert.Nil(f.Record(ctx, "a"))
+	assert.Nil(f.Record(ctx, "b"))
+	assert.Nil(f.Close())
+	assert.ErrorContains(f.Record(ctx, "c"), "closed")
+
+	// the expired ids are dropped when reopened
+	data, err := os.ReadFile(path)
+	assert.Nil(err)
+	assert.Nil(os.WriteFile(path, append(data, "c 1\n"...), 0o600))
+	f, err = OpenFileDedupStore(path, time.Hour)
+	assert.Nil(err)
+	defer f.Close()
+	for id, want := range map[string]bool{"a": true, "b": true, "c": false, "d": false} {
+		seen, err := f.Seen(ctx, id)
+		assert.Nil(err)
+		assert.Equal(want, seen, id)
+	}
+	data, err = os.ReadFile(path)
+	assert.Nil(err)
+	assert.Equal(2, strings.Count(string(data), "\n"))
+}
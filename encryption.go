@@ -0,0 +1,183 @@
+package bot
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	encryptedCategoryPrefix = "ENCRYPTED_"
+	plainCategoryPrefix     = "PLAIN_"
+
+	defaultSessionCacheTTL = 10 * time.Minute
+)
+
+type RecipientSessionView struct {
+	SessionId string `json:"session_id"`
+}
+
+// sessionCache keeps the sessions of the recipients, the sessions of a user
+// are fetched again after the ttl or a checksum mismatch.
+type sessionCache struct {
+	mutex   sync.Mutex
+	ttl     time.Duration
+	entries map[string]*sessionEntry
+}
+
+type sessionEntry struct {
+	sessions []*Session
+	expireAt time.Time
+}
+
+func newSessionCache(ttl time.Duration) *sessionCache {
+	return &sessionCache{ttl: ttl, entries: make(map[string]*sessionEntry)}
+}
+
+func (sc *sessionCache) get(userId string) ([]*Session, bool) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	e := sc.entries[userId]
+	if e == nil || time.Now().After(e.expireAt) {
+		return nil, false
+	}
+	return e.sessions, true
+}
+
+func (sc *sessionCache) set(userId string, sessions []*Session) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	sc.entries[userId] = &sessionEntry{sessions: sessions, expireAt: time.Now().Add(sc.ttl)}
+}
+
+func (sc *sessionCache) invalidate(userIds ...string) {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	for _, id := range userIds {
+		delete(sc.entries, id)
+	}
+}
+
+// SetEncryption makes PostMessages send the PLAIN_* messages as ENCRYPTED_*
+// ones, the ENCRYPTED_* messages are always encrypted.
+func (c *Client) SetEncryption(enabled bool) {
+	c.encrypt = enabled
+}
+
+// UserSessions returns the sessions of the user, from the cache unless they
+// are older than the cache ttl.
+func (c *Client) UserSessions(ctx context.Context, userId string) ([]*Session, error) {
+	if sessions, ok := c.sessions.get(userId); ok {
+		return sessions, nil
+	}
+	views, err := c.FetchUserSession(ctx, []string{userId})
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*Session
+	for _, v := range views {
+		if v.UserId != userId || v.PublicKey == "" {
+			continue
+		}
+		sessions = append(sessions, &Session{UserID: v.UserId, SessionID: v.SessionId, PublicKey: v.PublicKey})
+	}
+	c.sessions.set(userId, sessions)
+	return sessions, nil
+}
+
+func (c *Client) InvalidateUserSessions(userIds ...string) {
+	c.sessions.invalidate(userIds...)
+}
+
+// encryptMessage returns msg encrypted for all sessions of the recipient, or
+// msg itself when it doesn't need to be encrypted. A message to a user without
+// sessions, or a PLAIN_* message without recipient, is sent in plain.
+func (c *Client) encryptMessage(ctx context.Context, msg *MessageRequest) (*MessageRequest, error) {
+	category := msg.Category
+	if c.encrypt && strings.HasPrefix(category, plainCategoryPrefix) {
+		category = encryptedCategoryPrefix + strings.TrimPrefix(category, plainCategoryPrefix)
+	}
+	if !strings.HasPrefix(category, encryptedCategoryPrefix) || msg.Checksum != "" {
+		return msg, nil
+	}
+	if msg.RecipientId == "" {
+		// a group message is sent in plain, unless asked to be encrypted
+		if category != msg.Category {
+			return msg, nil
+		}
+		return nil, fmt.Errorf("encrypted message %s without recipient", msg.MessageId)
+	}
+	sessions, err := c.UserSessions(ctx, msg.RecipientId)
+	if err != nil {
+		return nil, err
+	}
+	m := *msg
+	if len(sessions) == 0 {
+		m.Category = plainCategoryPrefix + strings.TrimPrefix(category, encryptedCategoryPrefix)
+		return &m, nil
+	}
+	data, err := messageData(MessageView{MessageId: msg.MessageId, Category: msg.Category, DataBase64: msg.DataBase64})
+	if err != nil {
+		return nil, err
+	}
+	private, err := c.sessionPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	m.DataBase64, err = EncryptMessageData(base64.RawURLEncoding.EncodeToString(data), sessions, private)
+	if err != nil {
+		return nil, err
+	}
+	m.Category = category
+	m.Checksum = GenerateUserChecksum(slices.Clone(sessions))
+	m.RecipientSessions = make([]RecipientSessionView, len(sessions))
+	for i, s := range sessions {
+		m.RecipientSessions[i] = RecipientSessionView{SessionId: s.SessionID}
+	}
+	return &m, nil
+}
+
+// decryptMessage returns msg with the decrypted data and the PLAIN_* category,
+// or msg itself when it's not encrypted for the session of c.
+func (c *Client) decryptMessage(ctx context.Context, msg MessageView) MessageView {
+	if !strings.HasPrefix(msg.Category, encryptedCategoryPrefix) {
+		return msg
+	}
+	data, err := messageData(msg)
+	if err != nil {
+		c.log().WarnContext(ctx, "decrypt message", "message_id", msg.MessageId, "error", err)
+		return msg
+	}
+	private, err := c.sessionPrivateKey()
+	if err != nil {
+		c.log().WarnContext(ctx, "decrypt message", "message_id", msg.MessageId, "error", err)
+		return msg
+	}
+	plain, err := DecryptMessageData(base64.RawURLEncoding.EncodeToString(data), c.user.SessionId, private)
+	if err != nil || plain == "" {
+		c.log().WarnContext(ctx, "decrypt message", "message_id", msg.MessageId, "session_id", c.user.SessionId, "error", err)
+		return msg
+	}
+	msg.Category = plainCategoryPrefix + strings.TrimPrefix(msg.Category, encryptedCategoryPrefix)
+	msg.DataBase64 = plain
+	return msg
+}
+
+// sessionPrivateKey returns the ed25519 private key of the session in the
+// raw url base64 used by the message encryption.
+func (c *Client) sessionPrivateKey() (string, error) {
+	if c.user == nil {
+		return "", fmt.Errorf("no session to encrypt messages")
+	}
+	seed, err := hex.DecodeString(c.user.SessionPrivateKey)
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return "", fmt.Errorf("invalid session private key")
+	}
+	return base64.RawURLEncoding.EncodeToString(ed25519.NewKeyFromSeed(seed)), nil
+}
@@ -3,10 +3,65 @@ package bot
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"runtime"
+	"strings"
+)
+
+// error codes documented by the Mixin API
+const (
+	ErrorCodeUnauthorized             = 401
+	ErrorCodeForbidden                = 403
+	ErrorCodeNotFound                 = 404
+	ErrorCodeTooManyRequests          = 429
+	ErrorCodeInternalServer           = 500
+	ErrorCodeBlazeServer              = 7000
+	ErrorCodeBlazeTimeout             = 7001
+	ErrorCodeBadData                  = 10002
+	ErrorCodeGroupChatFull            = 20116
+	ErrorCodeInsufficientBalance      = 20117
+	ErrorCodeInvalidPINFormat         = 20118
+	ErrorCodeInvalidPIN               = 20119
+	ErrorCodeAmountTooSmall           = 20120
+	ErrorCodeExpiredAuthorization     = 20121
+	ErrorCodeInsufficientFee          = 20124
+	ErrorCodeTransferPaid             = 20125
+	ErrorCodeWithdrawalAmountTooSmall = 20127
+	ErrorCodeInvalidWithdrawalMemo    = 20131
+	ErrorCodeChecksumInvalid          = 20140
+	ErrorCodeChainNotInSync           = 30100
+	ErrorCodeInvalidAddress           = 30102
+	ErrorCodeInsufficientPool         = 30103
+)
+
+// Sentinel errors to use with errors.Is, an Error matches a sentinel when
+// both have the same code, whatever the status and description.
+var (
+	ErrUnauthorized             = Error{Status: http.StatusAccepted, Code: ErrorCodeUnauthorized, Description: "Unauthorized, maybe invalid token."}
+	ErrForbidden                = Error{Status: http.StatusAccepted, Code: ErrorCodeForbidden, Description: http.StatusText(http.StatusForbidden)}
+	ErrNotFound                 = Error{Status: http.StatusAccepted, Code: ErrorCodeNotFound, Description: "The endpoint is not found."}
+	ErrRateLimited              = Error{Status: http.StatusTooManyRequests, Code: ErrorCodeTooManyRequests, Description: http.StatusText(http.StatusTooManyRequests)}
+	ErrInternalServer           = Error{Status: http.StatusInternalServerError, Code: ErrorCodeInternalServer, Description: http.StatusText(http.StatusInternalServerError)}
+	ErrBlazeServer              = Error{Status: http.StatusInternalServerError, Code: ErrorCodeBlazeServer, Description: "Blaze server error."}
+	ErrBlazeTimeout             = Error{Status: http.StatusAccepted, Code: ErrorCodeBlazeTimeout, Description: "Blaze operation timeout."}
+	ErrBadData                  = Error{Status: http.StatusAccepted, Code: ErrorCodeBadData, Description: "The request data has invalid field."}
+	ErrGroupChatFull            = Error{Status: http.StatusAccepted, Code: ErrorCodeGroupChatFull, Description: "The group chat is full."}
+	ErrInsufficientBalance      = Error{Status: http.StatusAccepted, Code: ErrorCodeInsufficientBalance, Description: "Insufficient balance."}
+	ErrInvalidPINFormat         = Error{Status: http.StatusAccepted, Code: ErrorCodeInvalidPINFormat, Description: "PIN format error."}
+	ErrInvalidPIN               = Error{Status: http.StatusAccepted, Code: ErrorCodeInvalidPIN, Description: "PIN incorrect."}
+	ErrAmountTooSmall           = Error{Status: http.StatusAccepted, Code: ErrorCodeAmountTooSmall, Description: "Transfer amount is too small."}
+	ErrExpiredAuthorization     = Error{Status: http.StatusAccepted, Code: ErrorCodeExpiredAuthorization, Description: "Authorization code has expired."}
+	ErrInsufficientFee          = Error{Status: http.StatusAccepted, Code: ErrorCodeInsufficientFee, Description: "Insufficient transaction fee."}
+	ErrTransferPaid             = Error{Status: http.StatusAccepted, Code: ErrorCodeTransferPaid, Description: "The transfer has been paid by someone else."}
+	ErrWithdrawalAmountTooSmall = Error{Status: http.StatusAccepted, Code: ErrorCodeWithdrawalAmountTooSmall, Description: "The withdrawal amount is too small."}
+	ErrInvalidWithdrawalMemo    = Error{Status: http.StatusAccepted, Code: ErrorCodeInvalidWithdrawalMemo, Description: "Withdrawal memo format error."}
+	ErrChecksumInvalid          = Error{Status: http.StatusAccepted, Code: ErrorCodeChecksumInvalid, Description: "The conversation checksum is invalid."}
+	ErrChainNotInSync           = Error{Status: http.StatusAccepted, Code: ErrorCodeChainNotInSync, Description: "The chain of the asset is not in sync."}
+	ErrInvalidAddress           = Error{Status: http.StatusAccepted, Code: ErrorCodeInvalidAddress, Description: "Invalid withdrawal address."}
+	ErrInsufficientPool         = Error{Status: http.StatusAccepted, Code: ErrorCodeInsufficientPool, Description: "Insufficient pool."}
 )
 
 type Error struct {
@@ -15,6 +70,7 @@ type Error struct {
 	Description string `json:"description"`
 	Extra       any    `json:"extra,omitempty"`
 	trace       string
+	cause       error
 }
 
 func (sessionError Error) Error() string {
@@ -25,6 +81,83 @@ func (sessionError Error) Error() string {
 	return string(str)
 }
 
+func (sessionError Error) Unwrap() error {
+	return sessionError.cause
+}
+
+func (sessionError Error) Is(target error) bool {
+	switch t := target.(type) {
+	case Error:
+		return t.Code == sessionError.Code
+	case *Error:
+		return t != nil && t.Code == sessionError.Code
+	}
+	return false
+}
+
+// AsError finds the first Error in the chain of err, whether it was
+// returned as a value or a pointer.
+func AsError(err error) (Error, bool) {
+	var e Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	var p *Error
+	if errors.As(err, &p) && p != nil {
+		return *p, true
+	}
+	return Error{}, false
+}
+
+func IsUnauthorized(err error) bool {
+	return errors.Is(err, ErrUnauthorized)
+}
+
+func IsForbidden(err error) bool {
+	return errors.Is(err, ErrForbidden)
+}
+
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound)
+}
+
+func IsRateLimited(err error) bool {
+	return errors.Is(err, ErrRateLimited)
+}
+
+func IsServerError(err error) bool {
+	return errors.Is(err, ErrInternalServer) || errors.Is(err, ErrBlazeServer)
+}
+
+// IsInsufficientBalance also matches the UtxoInsufficientError returned when
+// there are not enough unspent outputs to build a transaction.
+func IsInsufficientBalance(err error) bool {
+	return errors.Is(err, ErrInsufficientBalance)
+}
+
+func IsInvalidPIN(err error) bool {
+	return errors.Is(err, ErrInvalidPIN) || errors.Is(err, ErrInvalidPINFormat)
+}
+
+func IsInsufficientFee(err error) bool {
+	return errors.Is(err, ErrInsufficientFee)
+}
+
+func IsInvalidAddress(err error) bool {
+	return errors.Is(err, ErrInvalidAddress)
+}
+
+// IsUtxoLocked reports whether the outputs of a transaction are already
+// locked by another transaction, which is reported as invalid request data.
+func IsUtxoLocked(err error) bool {
+	e, ok := AsError(err)
+	if !ok || e.Code != ErrorCodeBadData {
+		return false
+	}
+	d := strings.ToLower(e.Description)
+	return strings.Contains(d, "locked by") || strings.Contains(d, "by other transaction")
+}
+
 func BlazeServerError(ctx context.Context, err error) Error {
 	description := "Blaze server error."
 	return createError(ctx, http.StatusInternalServerError, 7000, description, err)
@@ -72,5 +205,6 @@ func createError(ctx context.Context, status, code int, description string, err
 		Code:        code,
 		Description: description,
 		trace:       trace,
+		cause:       err,
 	}
 }
@@ -0,0 +1,44 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/MixinNetwork/mixin/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorTaxonomy(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	resp := &Error{Status: 202, Code: 404, Description: "The endpoint is not found."}
+	assert.True(IsNotFound(resp))
+	assert.True(IsNotFound(fmt.Errorf("read user => %w", *resp)))
+	assert.False(IsForbidden(resp))
+
+	var err error = ServerError(ctx, Error{Status: 202, Code: 20119, Description: "PIN incorrect."})
+	assert.True(IsServerError(err))
+	assert.True(IsInvalidPIN(err))
+
+	err = BlazeServerError(ctx, ForbiddenError(ctx))
+	assert.True(errors.Is(err, ErrBlazeServer))
+	assert.True(IsForbidden(err))
+	e, ok := AsError(err)
+	assert.True(ok)
+	assert.Equal(ErrorCodeBlazeServer, e.Code)
+
+	err = fmt.Errorf("requestUnspentOutputsForRecipients(%s) => %w", "xin", &UtxoInsufficientError{
+		TotalInput:  common.NewIntegerFromString("1"),
+		TotalOutput: common.NewIntegerFromString("2"),
+	})
+	assert.True(IsInsufficientBalance(err))
+	var ue *UtxoInsufficientError
+	assert.True(errors.As(err, &ue))
+
+	locked := &Error{Status: 202, Code: 10002, Description: "inputs locked by other transaction"}
+	assert.True(IsUtxoLocked(locked))
+	assert.False(IsUtxoLocked(BadDataError(ctx)))
+}
@@ -3,6 +3,9 @@ package main
 import (
 	"context"
 	"log"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/MixinNetwork/bot-api-go-client/v3"
 )
@@ -23,15 +26,18 @@ func (f mixinBlazeHandler) SyncAck() bool {
 }
 
 func main() {
-	var ctx context.Context
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	h := func(ctx context.Context, botMsg bot.MessageView, clientID string) error {
 		log.Println(botMsg)
 		return nil
 	}
-	for {
-		client := bot.NewBlazeClient("", "", "")
-		if err := client.Loop(ctx, mixinBlazeHandler(h)); err != nil {
-			log.Println("test...", err)
-		}
+	supervisor := bot.NewBlazeSupervisor(10)
+	supervisor.OnStateChange = func(state bot.BlazeState, err error) {
+		log.Println("blaze", state, err)
+	}
+	client := bot.NewBlazeClient("", "", "")
+	if err := client.Supervise(ctx, mixinBlazeHandler(h), supervisor); err != nil {
+		log.Println("test...", err)
 	}
 }
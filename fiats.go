@@ -11,7 +11,11 @@ type Fiat struct {
 }
 
 func GetFiats(ctx context.Context) ([]*Fiat, error) {
-	body, err := SimpleRequest(ctx, "GET", "/external/fiats", nil)
+	return defaultClient.GetFiats(ctx)
+}
+
+func (c *Client) GetFiats(ctx context.Context) ([]*Fiat, error) {
+	body, err := c.SimpleRequest(ctx, "GET", "/external/fiats", nil)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -30,7 +34,11 @@ func GetFiats(ctx context.Context) ([]*Fiat, error) {
 }
 
 func Fiats(ctx context.Context) ([]*Fiat, error) {
-	body, err := Request(ctx, "GET", "/external/fiats", nil, "")
+	return defaultClient.Fiats(ctx)
+}
+
+func (c *Client) Fiats(ctx context.Context) ([]*Fiat, error) {
+	body, err := c.Request(ctx, "GET", "/external/fiats", nil, "")
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -127,7 +127,12 @@ type Inscription struct {
 
 // ReadCollection reads collection information from Mixin API
 func ReadCollection(ctx context.Context, collectionHash string) (*Collection, error) {
-	body, err := Request(ctx, "GET", "/safe/inscriptions/collections/"+collectionHash, nil, "")
+	return defaultClient.ReadCollection(ctx, collectionHash)
+}
+
+// ReadCollection reads collection information from Mixin API
+func (c *Client) ReadCollection(ctx context.Context, collectionHash string) (*Collection, error) {
+	body, err := c.Request(ctx, "GET", "/safe/inscriptions/collections/"+collectionHash, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +156,12 @@ func ReadCollection(ctx context.Context, collectionHash string) (*Collection, er
 
 // ReadInscription reads inscription information from Mixin API
 func ReadInscription(ctx context.Context, inscriptionHash string) (*Inscription, error) {
-	body, err := Request(ctx, "GET", "/safe/inscriptions/items/"+inscriptionHash, nil, "")
+	return defaultClient.ReadInscription(ctx, inscriptionHash)
+}
+
+// ReadInscription reads inscription information from Mixin API
+func (c *Client) ReadInscription(ctx context.Context, inscriptionHash string) (*Inscription, error) {
+	body, err := c.Request(ctx, "GET", "/safe/inscriptions/items/"+inscriptionHash, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -175,8 +185,13 @@ func ReadInscription(ctx context.Context, inscriptionHash string) (*Inscription,
 
 // ReadCollectionItems reads all items in a collection from Mixin API
 func ReadCollectionItems(ctx context.Context, collectionHash string) ([]*Inscription, error) {
+	return defaultClient.ReadCollectionItems(ctx, collectionHash)
+}
+
+// ReadCollectionItems reads all items in a collection from Mixin API
+func (c *Client) ReadCollectionItems(ctx context.Context, collectionHash string) ([]*Inscription, error) {
 	path := fmt.Sprintf("/safe/inscriptions/collections/%s/items", collectionHash)
-	body, err := Request(ctx, "GET", path, nil, "")
+	body, err := c.Request(ctx, "GET", path, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,126 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"strconv"
+	"time"
+)
+
+// paginate pages through a list endpoint until a short page is returned. The
+// offset of the next page is derived from the last item, and items repeated
+// from the previous page at the offset boundary are skipped by key. A full
+// page sharing one offset can't be paged past, so it yields an error instead
+// of dropping the remaining items.
+func paginate[T any](ctx context.Context, limit int, fetch func(offset string) ([]T, error), offset func(T) string, key func(T) string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		var next string
+		seen := make(map[string]bool)
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			items, err := fetch(next)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			page := make(map[string]bool, len(items))
+			for _, item := range items {
+				k := key(item)
+				page[k] = true
+				if seen[k] {
+					continue
+				}
+				if !yield(item, nil) {
+					return
+				}
+			}
+			if len(items) < limit || len(items) == 0 {
+				return
+			}
+			last := offset(items[len(items)-1])
+			if last == next {
+				yield(zero, fmt.Errorf("pagination stalled at offset %s", last))
+				return
+			}
+			next, seen = last, page
+		}
+	}
+}
+
+func IterOutputs(ctx context.Context, membersHash string, threshold byte, assetId, state string, limit int, auth Authorizer) iter.Seq2[*Output, error] {
+	return defaultClient.WithAuthorizer(auth).IterOutputs(ctx, membersHash, threshold, assetId, state, limit)
+}
+
+func (c *Client) IterOutputs(ctx context.Context, membersHash string, threshold byte, assetId, state string, limit int) iter.Seq2[*Output, error] {
+	return paginate(ctx, limit, func(offset string) ([]*Output, error) {
+		sequence, _ := strconv.ParseInt(offset, 10, 64)
+		return c.ListOutputs(ctx, membersHash, threshold, assetId, state, sequence, limit)
+	}, func(o *Output) string {
+		return strconv.FormatInt(o.Sequence, 10)
+	}, func(o *Output) string {
+		return o.OutputID
+	})
+}
+
+func IterSafeSnapshots(ctx context.Context, app, assetId, opponent string, limit int, auth Authorizer) iter.Seq2[*SafeSnapshot, error] {
+	return defaultClient.WithAuthorizer(auth).IterSafeSnapshots(ctx, app, assetId, opponent, limit)
+}
+
+func (c *Client) IterSafeSnapshots(ctx context.Context, app, assetId, opponent string, limit int) iter.Seq2[*SafeSnapshot, error] {
+	return paginate(ctx, limit, func(offset string) ([]*SafeSnapshot, error) {
+		return c.SafeSnapshots(ctx, limit, app, assetId, opponent, offset)
+	}, func(s *SafeSnapshot) string {
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	}, func(s *SafeSnapshot) string {
+		return s.SnapshotID
+	})
+}
+
+// state: spent, unspent, signed
+func IterMultisigs(ctx context.Context, membersHash, threshold, state string, limit int, auth Authorizer) iter.Seq2[*MultisigUTXO, error] {
+	return defaultClient.WithAuthorizer(auth).IterMultisigs(ctx, membersHash, threshold, state, limit)
+}
+
+// state: spent, unspent, signed
+func (c *Client) IterMultisigs(ctx context.Context, membersHash, threshold, state string, limit int) iter.Seq2[*MultisigUTXO, error] {
+	return paginate(ctx, limit, func(offset string) ([]*MultisigUTXO, error) {
+		return c.ReadMultisigs(ctx, limit, offset, membersHash, threshold, state)
+	}, func(m *MultisigUTXO) string {
+		return m.UpdatedAt.Format(time.RFC3339Nano)
+	}, func(m *MultisigUTXO) string {
+		return m.UTXOId
+	})
+}
+
+func IterSnapshots(ctx context.Context, assetId, order string, limit int, auth Authorizer) iter.Seq2[*LegacySnapshot, error] {
+	return defaultClient.WithAuthorizer(auth).IterSnapshots(ctx, assetId, order, limit)
+}
+
+func (c *Client) IterSnapshots(ctx context.Context, assetId, order string, limit int) iter.Seq2[*LegacySnapshot, error] {
+	return paginate(ctx, limit, func(offset string) ([]*LegacySnapshot, error) {
+		return c.Snapshots(ctx, limit, offset, assetId, order)
+	}, func(s *LegacySnapshot) string {
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	}, func(s *LegacySnapshot) string {
+		return s.SnapshotId
+	})
+}
+
+func IterNetworkSnapshots(ctx context.Context, assetId, order string, limit int) iter.Seq2[*LegacySnapshotShort, error] {
+	return defaultClient.WithoutAuthorizer().IterNetworkSnapshots(ctx, assetId, order, limit)
+}
+
+func (c *Client) IterNetworkSnapshots(ctx context.Context, assetId, order string, limit int) iter.Seq2[*LegacySnapshotShort, error] {
+	return paginate(ctx, limit, func(offset string) ([]*LegacySnapshotShort, error) {
+		return c.NetworkSnapshots(ctx, limit, offset, assetId, order)
+	}, func(s *LegacySnapshotShort) string {
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	}, func(s *LegacySnapshotShort) string {
+		return s.SnapshotId
+	})
+}
@@ -0,0 +1,53 @@
+package bot
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaginate(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	// the items 0-4 have the offsets 0,1,1,1,2, a page starts at its offset
+	offsets := []int{0, 1, 1, 1, 2}
+	fetch := func(limit int) func(string) ([]int, error) {
+		return func(offset string) ([]int, error) {
+			start := 0
+			if offset != "" {
+				start, _ = strconv.Atoi(offset)
+			}
+			var page []int
+			for i, o := range offsets {
+				if o >= start && len(page) < limit {
+					page = append(page, i)
+				}
+			}
+			return page, nil
+		}
+	}
+	offset := func(i int) string { return strconv.Itoa(offsets[i]) }
+	key := func(i int) string { return strconv.Itoa(i) }
+
+	var items []int
+	for i, err := range paginate(ctx, 4, fetch(4), offset, key) {
+		assert.Nil(err)
+		items = append(items, i)
+	}
+	assert.Equal([]int{0, 1, 2, 3, 4}, items)
+
+	items = nil
+	var failed error
+	for i, err := range paginate(ctx, 2, fetch(2), offset, key) {
+		if err != nil {
+			failed = err
+			break
+		}
+		items = append(items, i)
+	}
+	assert.ErrorContains(failed, "pagination stalled at offset 1")
+	assert.Equal([]int{0, 1, 2}, items)
+}
@@ -6,6 +6,10 @@ import (
 )
 
 func CallKernelRPC(ctx context.Context, user *SafeUser, method string, params ...any) ([]byte, error) {
+	return defaultClient.WithSafeUser(user).CallKernelRPC(ctx, method, params...)
+}
+
+func (c *Client) CallKernelRPC(ctx context.Context, method string, params ...any) ([]byte, error) {
 	p := map[string]any{
 		"method": method,
 		"params": params,
@@ -15,11 +19,11 @@ func CallKernelRPC(ctx context.Context, user *SafeUser, method string, params ..
 		return nil, err
 	}
 
-	token, err := SignAuthenticationToken("POST", "/external/kernel", string(data), user)
+	token, err := c.sign("POST", "/external/kernel", string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/external/kernel", data, token)
+	body, err := c.Request(ctx, "POST", "/external/kernel", data, token)
 	if err != nil {
 		return nil, err
 	}
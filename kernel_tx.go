@@ -10,7 +10,13 @@ import (
 
 // this function send a raw transaction to mixin api users from a kernel account
 // this account should have private view key and private spend key
-func SendKernelTransactionFromAccount(ctx context.Context, asset crypto.Hash, receivers []string, threshold byte, amount common.Integer, inputs []*common.UTXO, account *common.Address, traceId, extra string, safeUser *SafeUser) string {
+func SendKernelTransactionFromAccount(ctx context.Context, asset crypto.Hash, receivers []string, threshold byte, amount common.Integer, inputs []*common.UTXO, account *common.Address, traceId, extra string, auth Authorizer) string {
+	return defaultClient.WithAuthorizer(auth).SendKernelTransactionFromAccount(ctx, asset, receivers, threshold, amount, inputs, account, traceId, extra)
+}
+
+// this function send a raw transaction to mixin api users from a kernel account
+// this account should have private view key and private spend key
+func (c *Client) SendKernelTransactionFromAccount(ctx context.Context, asset crypto.Hash, receivers []string, threshold byte, amount common.Integer, inputs []*common.UTXO, account *common.Address, traceId, extra string) string {
 	var total common.Integer
 	tx := common.NewTransactionV5(asset)
 	for _, in := range inputs {
@@ -27,7 +33,7 @@ func SendKernelTransactionFromAccount(ctx context.Context, asset crypto.Hash, re
 		Index:     0,
 		Hint:      traceId,
 	}
-	ghostKeys, err := RequestSafeGhostKeys(ctx, []*GhostKeyRequest{r}, safeUser)
+	ghostKeys, err := c.RequestSafeGhostKeys(ctx, []*GhostKeyRequest{r})
 	if err != nil {
 		panic(err)
 	}
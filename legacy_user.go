@@ -18,7 +18,6 @@ import (
 	"encoding/pem"
 	"errors"
 	"io"
-	"log"
 	"time"
 )
 
@@ -38,6 +37,10 @@ type KeystoreLegacy struct {
 }
 
 func UpgradeLegacyUser(ctx context.Context, kl *KeystoreLegacy) (*UserUpgrade, error) {
+	return defaultClient.UpgradeLegacyUser(ctx, kl)
+}
+
+func (c *Client) UpgradeLegacyUser(ctx context.Context, kl *KeystoreLegacy) (*UserUpgrade, error) {
 	privBlock, _ := pem.Decode([]byte(kl.PrivateKey))
 	if privBlock == nil {
 		return nil, errors.New("invalid pem private key")
@@ -87,7 +90,7 @@ func UpgradeLegacyUser(ctx context.Context, kl *KeystoreLegacy) (*UserUpgrade, e
 	seed := hash[:32]
 	privEd25519 := ed25519.NewKeyFromSeed(seed)
 	pubEd25519 := privEd25519.Public()
-	log.Printf("session_private_key: %x", seed)
+	c.log().InfoContext(ctx, "upgrade legacy user", "session_id", kl.SessionId)
 
 	data, _ := json.Marshal(map[string]string{
 		"session_secret_legacy": base64.RawURLEncoding.EncodeToString(pubBytes),
@@ -95,7 +98,7 @@ func UpgradeLegacyUser(ctx context.Context, kl *KeystoreLegacy) (*UserUpgrade, e
 		"session_id":            kl.SessionId,
 		"pin":                   base64.RawURLEncoding.EncodeToString(ciphertext),
 	})
-	body, err := Request(ctx, "POST", "/legacy/users", data, "")
+	body, err := c.Request(ctx, "POST", "/legacy/users", data, "")
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
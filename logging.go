@@ -0,0 +1,112 @@
+package bot
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+)
+
+const redactedValue = "[REDACTED]"
+
+// secretLogKeys are attribute keys whose values never reach a log handler,
+// matched case insensitively and also inside groups.
+var secretLogKeys = map[string]bool{
+	"session_private_key": true,
+	"spend_private_key":   true,
+	"private_key":         true,
+	"spend_key":           true,
+	"pin":                 true,
+	"pin_base64":          true,
+	"pin_token":           true,
+	"pin_token_base64":    true,
+	"encrypted_pin":       true,
+	"tip_body":            true,
+	"seed":                true,
+	"authorization":       true,
+	"access_token":        true,
+	"session_secret":      true,
+	"client_secret":       true,
+}
+
+// RedactHandler removes secrets from the records passed to the wrapped
+// handler, all loggers of the library are wrapped with it.
+type RedactHandler struct {
+	next slog.Handler
+}
+
+func NewRedactHandler(next slog.Handler) *RedactHandler {
+	if rh, ok := next.(*RedactHandler); ok {
+		return rh
+	}
+	return &RedactHandler{next: next}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	nr := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		nr.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, nr)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = redactAttr(a)
+	}
+	return &RedactHandler{next: h.next.WithAttrs(redacted)}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if secretLogKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redactedValue)
+	}
+	v := a.Value.Resolve()
+	if v.Kind() != slog.KindGroup {
+		return slog.Attr{Key: a.Key, Value: v}
+	}
+	group := v.Group()
+	attrs := make([]any, len(group))
+	for i, g := range group {
+		attrs[i] = redactAttr(g)
+	}
+	return slog.Group(a.Key, attrs...)
+}
+
+// LogValue keeps the keys of a SafeUser out of logs.
+func (su SafeUser) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("user_id", su.UserId),
+		slog.String("session_id", su.SessionId),
+	)
+}
+
+func (c *Client) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		c.logger = nil
+		return
+	}
+	c.logger = slog.New(NewRedactHandler(logger.Handler()))
+}
+
+func SetLogger(logger *slog.Logger) {
+	defaultClient.SetLogger(logger)
+}
+
+// log returns the logger of the client, or the current slog default wrapped
+// by the RedactHandler when none is set.
+func (c *Client) log() *slog.Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return slog.New(NewRedactHandler(slog.Default().Handler()))
+}
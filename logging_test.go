@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedactHandler(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewRedactHandler(slog.NewJSONHandler(&buf, nil)))
+	su := &SafeUser{
+		UserId:            "user",
+		SessionId:         "session",
+		SessionPrivateKey: "session-secret",
+		SpendPrivateKey:   "spend-secret",
+	}
+	logger.With("pin_base64", "pin-secret").Info("redact",
+		"user", su,
+		slog.Group("keystore", "session_private_key", "group-secret", "app_id", "app"),
+		"Authorization", "Bearer token-secret")
+	out := buf.String()
+	assert.Contains(out, `"user":{"user_id":"user","session_id":"session"}`)
+	assert.Contains(out, `"app_id":"app"`)
+	assert.NotContains(out, "secret")
+	assert.Equal(3, bytes.Count(buf.Bytes(), []byte(redactedValue)))
+
+	buf.Reset()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer s.Close()
+	c := NewClient(nil)
+	c.SetBaseUri(s.URL)
+	c.SetLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))
+	_, err := c.RequestWithId(context.Background(), "GET", "/me", nil, "token", "request-id")
+	assert.Nil(err)
+	out = buf.String()
+	assert.Contains(out, `"path":"/me"`)
+	assert.Contains(out, `"request_id":"request-id"`)
+	assert.Contains(out, `"status":200`)
+}
@@ -0,0 +1,216 @@
+package bot
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"time"
+)
+
+const thumbnailSize = 64
+
+func SendImage(ctx context.Context, conversationId, recipientId string, r io.Reader, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).SendImage(ctx, conversationId, recipientId, r)
+}
+
+// SendImage uploads a JPEG, PNG or GIF image and sends it, the dimensions,
+// mime type and thumbnail are read from the image.
+func (c *Client) SendImage(ctx context.Context, conversationId, recipientId string, r io.Reader) error {
+	msg, err := c.imageMessage(ctx, conversationId, recipientId, r)
+	if err != nil {
+		return err
+	}
+	return c.PostMessageRequest(ctx, msg)
+}
+
+func SendFile(ctx context.Context, conversationId, recipientId, name, mime string, r io.Reader, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).SendFile(ctx, conversationId, recipientId, name, mime, r)
+}
+
+// SendFile uploads a file and sends it as a PLAIN_DATA message, the mime
+// type is detected from the bytes when empty.
+func (c *Client) SendFile(ctx context.Context, conversationId, recipientId, name, mime string, r io.Reader) error {
+	msg, err := c.fileMessage(ctx, conversationId, recipientId, name, mime, r)
+	if err != nil {
+		return err
+	}
+	return c.PostMessageRequest(ctx, msg)
+}
+
+func SendAudio(ctx context.Context, conversationId, recipientId, mime string, duration time.Duration, r io.Reader, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).SendAudio(ctx, conversationId, recipientId, mime, duration, r)
+}
+
+func (c *Client) SendAudio(ctx context.Context, conversationId, recipientId, mime string, duration time.Duration, r io.Reader) error {
+	msg, err := c.audioMessage(ctx, conversationId, recipientId, mime, duration, r)
+	if err != nil {
+		return err
+	}
+	return c.PostMessageRequest(ctx, msg)
+}
+
+func SendVideo(ctx context.Context, conversationId, recipientId, mime string, width, height int, duration time.Duration, r io.Reader, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).SendVideo(ctx, conversationId, recipientId, mime, width, height, duration, r)
+}
+
+func (c *Client) SendVideo(ctx context.Context, conversationId, recipientId, mime string, width, height int, duration time.Duration, r io.Reader) error {
+	msg, err := c.videoMessage(ctx, conversationId, recipientId, mime, width, height, duration, r)
+	if err != nil {
+		return err
+	}
+	return c.PostMessageRequest(ctx, msg)
+}
+
+func (b *BlazeClient) SendImage(ctx context.Context, conversationId, recipientId string, r io.Reader) error {
+	msg, err := b.client.imageMessage(ctx, conversationId, recipientId, r)
+	if err != nil {
+		return err
+	}
+	return b.createMessage(ctx, msg)
+}
+
+func (b *BlazeClient) SendFile(ctx context.Context, conversationId, recipientId, name, mime string, r io.Reader) error {
+	msg, err := b.client.fileMessage(ctx, conversationId, recipientId, name, mime, r)
+	if err != nil {
+		return err
+	}
+	return b.createMessage(ctx, msg)
+}
+
+func (b *BlazeClient) SendAudio(ctx context.Context, conversationId, recipientId, mime string, duration time.Duration, r io.Reader) error {
+	msg, err := b.client.audioMessage(ctx, conversationId, recipientId, mime, duration, r)
+	if err != nil {
+		return err
+	}
+	return b.createMessage(ctx, msg)
+}
+
+func (b *BlazeClient) SendVideo(ctx context.Context, conversationId, recipientId, mime string, width, height int, duration time.Duration, r io.Reader) error {
+	msg, err := b.client.videoMessage(ctx, conversationId, recipientId, mime, width, height, duration, r)
+	if err != nil {
+		return err
+	}
+	return b.createMessage(ctx, msg)
+}
+
+func (c *Client) imageMessage(ctx context.Context, conversationId, recipientId string, r io.Reader) (*MessageRequest, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	thumbnail, err := imageThumbnail(img)
+	if err != nil {
+		return nil, err
+	}
+	upload, err := c.UploadAttachment(ctx, bytes.NewReader(data), "image/"+format)
+	if err != nil {
+		return nil, err
+	}
+	return attachmentMessage(conversationId, recipientId, MessageCategoryPlainImage, &ImageMessagePayload{
+		AttachmentId: upload.AttachmentId,
+		Width:        img.Bounds().Dx(),
+		Height:       img.Bounds().Dy(),
+		MimeType:     upload.MimeType,
+		Thumbnail:    thumbnail,
+		Size:         upload.Size,
+		Key:          upload.Key,
+		Digest:       upload.Digest,
+	})
+}
+
+func (c *Client) fileMessage(ctx context.Context, conversationId, recipientId, name, mime string, r io.Reader) (*MessageRequest, error) {
+	upload, err := c.UploadAttachment(ctx, r, mime)
+	if err != nil {
+		return nil, err
+	}
+	return attachmentMessage(conversationId, recipientId, MessageCategoryPlainData, &DataMessagePayload{
+		AttachmentId: upload.AttachmentId,
+		MimeType:     upload.MimeType,
+		Size:         upload.Size,
+		Name:         name,
+		Key:          upload.Key,
+		Digest:       upload.Digest,
+	})
+}
+
+func (c *Client) audioMessage(ctx context.Context, conversationId, recipientId, mime string, duration time.Duration, r io.Reader) (*MessageRequest, error) {
+	upload, err := c.UploadAttachment(ctx, r, mime)
+	if err != nil {
+		return nil, err
+	}
+	return attachmentMessage(conversationId, recipientId, MessageCategoryPlainAudio, &AudioMessagePayload{
+		AttachmentId: upload.AttachmentId,
+		MimeType:     upload.MimeType,
+		Size:         upload.Size,
+		Duration:     duration.Milliseconds(),
+		Key:          upload.Key,
+		Digest:       upload.Digest,
+	})
+}
+
+func (c *Client) videoMessage(ctx context.Context, conversationId, recipientId, mime string, width, height int, duration time.Duration, r io.Reader) (*MessageRequest, error) {
+	upload, err := c.UploadAttachment(ctx, r, mime)
+	if err != nil {
+		return nil, err
+	}
+	return attachmentMessage(conversationId, recipientId, MessageCategoryPlainVideo, &VideoMessagePayload{
+		AttachmentId: upload.AttachmentId,
+		MimeType:     upload.MimeType,
+		Width:        width,
+		Height:       height,
+		Size:         upload.Size,
+		Duration:     duration.Milliseconds(),
+		Key:          upload.Key,
+		Digest:       upload.Digest,
+	})
+}
+
+func attachmentMessage(conversationId, recipientId, category string, payload any) (*MessageRequest, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	return &MessageRequest{
+		ConversationId: conversationId,
+		RecipientId:    recipientId,
+		MessageId:      UuidNewV4().String(),
+		Category:       category,
+		DataBase64:     base64.RawURLEncoding.EncodeToString(data),
+	}, nil
+}
+
+// imageThumbnail scales img down to fit in thumbnailSize pixels, and returns
+// it as a base64 JPEG.
+func imageThumbnail(img image.Image) (string, error) {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w == 0 || h == 0 {
+		return "", nil
+	}
+	scale := max(w, h)
+	tw, th := max(w*thumbnailSize/scale, 1), max(h*thumbnailSize/scale, 1)
+	if scale <= thumbnailSize {
+		tw, th = w, h
+	}
+	thumb := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := range th {
+		for x := range tw {
+			thumb.Set(x, y, img.At(b.Min.X+x*w/tw, b.Min.Y+y*h/th))
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 60}); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
@@ -10,6 +10,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 
 	"golang.org/x/crypto/curve25519"
 )
@@ -28,6 +29,30 @@ type ImageMessagePayload struct {
 	MimeType     string `json:"mime_type"`
 	Thumbnail    string `json:"thumbnail"`
 	Size         int64  `json:"size"`
+	Key          []byte `json:"key,omitempty"`
+	Digest       []byte `json:"digest,omitempty"`
+}
+
+type AudioMessagePayload struct {
+	AttachmentId string `json:"attachment_id"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	Duration     int64  `json:"duration"` // milliseconds
+	WaveForm     []byte `json:"waveform,omitempty"`
+	Key          []byte `json:"key,omitempty"`
+	Digest       []byte `json:"digest,omitempty"`
+}
+
+type VideoMessagePayload struct {
+	AttachmentId string `json:"attachment_id"`
+	MimeType     string `json:"mime_type"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Size         int64  `json:"size"`
+	Duration     int64  `json:"duration"` // milliseconds
+	Thumbnail    string `json:"thumbnail,omitempty"`
+	Key          []byte `json:"key,omitempty"`
+	Digest       []byte `json:"digest,omitempty"`
 }
 
 type RecallMessagePayload struct {
@@ -43,6 +68,9 @@ type MessageRequest struct {
 	RepresentativeId string `json:"representative_id"`
 	QuoteMessageId   string `json:"quote_message_id"`
 	Silent           bool   `json:"silent"`
+
+	Checksum          string                 `json:"checksum,omitempty"`
+	RecipientSessions []RecipientSessionView `json:"recipient_sessions,omitempty"`
 }
 
 type ReceiptAcknowledgementRequest struct {
@@ -50,42 +78,57 @@ type ReceiptAcknowledgementRequest struct {
 	Status    string `json:"status"`
 }
 
-func PostMessageRequest(ctx context.Context, message *MessageRequest, user *SafeUser) error {
-	msg, err := json.Marshal(message)
-	if err != nil {
-		return err
-	}
-	accessToken, err := SignAuthenticationToken("POST", "/messages", string(msg), user)
-	if err != nil {
-		return err
-	}
-	body, err := Request(ctx, "POST", "/messages", msg, accessToken)
-	if err != nil {
-		return err
-	}
-	var resp struct {
-		Error Error `json:"error"`
-	}
-	err = json.Unmarshal(body, &resp)
-	if err != nil {
+func PostMessageRequest(ctx context.Context, message *MessageRequest, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).PostMessageRequest(ctx, message)
+}
+
+func (c *Client) PostMessageRequest(ctx context.Context, message *MessageRequest) error {
+	return c.sendMessages(ctx, []*MessageRequest{message}, false)
+}
+
+func PostMessages(ctx context.Context, messages []*MessageRequest, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).PostMessages(ctx, messages)
+}
+
+func (c *Client) PostMessages(ctx context.Context, messages []*MessageRequest) error {
+	return c.sendMessages(ctx, messages, true)
+}
+
+// sendMessages sends the messages again with the fresh sessions of the
+// recipients when the server rejects the checksum of an encrypted message.
+func (c *Client) sendMessages(ctx context.Context, messages []*MessageRequest, batch bool) error {
+	err := c.postMessages(ctx, messages, batch)
+	if !errors.Is(err, ErrChecksumInvalid) {
 		return err
 	}
-	if resp.Error.Code > 0 {
-		return resp.Error
+	for _, m := range messages {
+		c.InvalidateUserSessions(m.RecipientId)
 	}
-	return nil
+	return c.postMessages(ctx, messages, batch)
 }
 
-func PostMessages(ctx context.Context, messages []*MessageRequest, user *SafeUser) error {
-	msg, err := json.Marshal(messages)
+func (c *Client) postMessages(ctx context.Context, messages []*MessageRequest, batch bool) error {
+	encrypted := make([]*MessageRequest, len(messages))
+	for i, m := range messages {
+		e, err := c.encryptMessage(ctx, m)
+		if err != nil {
+			return err
+		}
+		encrypted[i] = e
+	}
+	var payload any = encrypted
+	if !batch {
+		payload = encrypted[0]
+	}
+	msg, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
-	accessToken, err := SignAuthenticationToken("POST", "/messages", string(msg), user)
+	accessToken, err := c.sign("POST", "/messages", string(msg))
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, "POST", "/messages", msg, accessToken)
+	body, err := c.Request(ctx, "POST", "/messages", msg, accessToken)
 	if err != nil {
 		return err
 	}
@@ -102,7 +145,11 @@ func PostMessages(ctx context.Context, messages []*MessageRequest, user *SafeUse
 	return nil
 }
 
-func PostMessage(ctx context.Context, conversationId, recipientId, messageId, category, dataBase64 string, user *SafeUser) error {
+func PostMessage(ctx context.Context, conversationId, recipientId, messageId, category, dataBase64 string, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).PostMessage(ctx, conversationId, recipientId, messageId, category, dataBase64)
+}
+
+func (c *Client) PostMessage(ctx context.Context, conversationId, recipientId, messageId, category, dataBase64 string) error {
 	request := MessageRequest{
 		ConversationId: conversationId,
 		RecipientId:    recipientId,
@@ -110,20 +157,24 @@ func PostMessage(ctx context.Context, conversationId, recipientId, messageId, ca
 		Category:       category,
 		DataBase64:     dataBase64,
 	}
-	return PostMessages(ctx, []*MessageRequest{&request}, user)
+	return c.PostMessages(ctx, []*MessageRequest{&request})
+}
+
+func PostAcknowledgements(ctx context.Context, requests []*ReceiptAcknowledgementRequest, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).PostAcknowledgements(ctx, requests)
 }
 
-func PostAcknowledgements(ctx context.Context, requests []*ReceiptAcknowledgementRequest, user *SafeUser) error {
+func (c *Client) PostAcknowledgements(ctx context.Context, requests []*ReceiptAcknowledgementRequest) error {
 	array, err := json.Marshal(requests)
 	if err != nil {
 		return err
 	}
 	path := "/acknowledgements"
-	accessToken, err := SignAuthenticationToken("POST", path, string(array), user)
+	accessToken, err := c.sign("POST", path, string(array))
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, "POST", path, array, accessToken)
+	body, err := c.Request(ctx, "POST", path, array, accessToken)
 	if err != nil {
 		return err
 	}
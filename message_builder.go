@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageBuilder builds a MessageRequest, which can be sent with
+// PostMessageRequest or BlazeClient.SendMessageRequest. The message id is
+// random unless set by MessageId, a fixed id makes the sending idempotent.
+//
+//	msg, err := NewMessage(conversationId, userId).Quote(id).Text("hi").Build()
+type MessageBuilder struct {
+	msg        MessageRequest
+	transcript []*TranscriptMessage
+	err        error
+}
+
+func NewMessage(conversationId, recipientId string) *MessageBuilder {
+	return &MessageBuilder{msg: MessageRequest{
+		ConversationId: conversationId,
+		RecipientId:    recipientId,
+		MessageId:      UuidNewV4().String(),
+	}}
+}
+
+func (m *MessageBuilder) MessageId(id string) *MessageBuilder {
+	m.msg.MessageId = id
+	return m
+}
+
+func (m *MessageBuilder) Quote(messageId string) *MessageBuilder {
+	m.msg.QuoteMessageId = messageId
+	return m
+}
+
+func (m *MessageBuilder) Silent(silent bool) *MessageBuilder {
+	m.msg.Silent = silent
+	return m
+}
+
+func (m *MessageBuilder) Representative(userId string) *MessageBuilder {
+	m.msg.RepresentativeId = userId
+	return m
+}
+
+func (m *MessageBuilder) Text(text string) *MessageBuilder {
+	return m.raw(MessageCategoryPlainText, []byte(text))
+}
+
+func (m *MessageBuilder) Post(markdown string) *MessageBuilder {
+	return m.raw(MessageCategoryPlainPost, []byte(markdown))
+}
+
+func (m *MessageBuilder) Sticker(stickerId string) *MessageBuilder {
+	return m.Payload(MessageCategoryPlainSticker, &StickerMessagePayload{StickerId: stickerId})
+}
+
+func (m *MessageBuilder) Contact(userId string) *MessageBuilder {
+	return m.Payload(MessageCategoryPlainContact, &ContactMessagePayload{UserId: userId})
+}
+
+func (m *MessageBuilder) Location(longitude, latitude float64, name, address string) *MessageBuilder {
+	return m.Payload(MessageCategoryPlainLocation, &LocationMessagePayload{
+		Longitude: longitude,
+		Latitude:  latitude,
+		Name:      name,
+		Address:   address,
+	})
+}
+
+func (m *MessageBuilder) Live(live *LiveMessagePayload) *MessageBuilder {
+	return m.Payload(MessageCategoryPlainLive, live)
+}
+
+// Transcript sends copies of messages with their transcript id set to the
+// final message id by Build.
+func (m *MessageBuilder) Transcript(messages []*TranscriptMessage) *MessageBuilder {
+	m.raw(MessageCategoryPlainTranscript, nil)
+	m.transcript = append([]*TranscriptMessage{}, messages...)
+	return m
+}
+
+func (m *MessageBuilder) AppCard(card *AppCardView) *MessageBuilder {
+	if card.Title == "" || len(card.Actions) > maximumButtons {
+		m.err = fmt.Errorf("invalid app card %s with %d actions", card.Title, len(card.Actions))
+	}
+	return m.Payload(MessageCategoryAppCard, card)
+}
+
+func (m *MessageBuilder) Buttons(buttons ...*AppButtonView) *MessageBuilder {
+	if len(buttons) == 0 || len(buttons) > maximumButtons {
+		m.err = fmt.Errorf("invalid buttons count %d, maximum is %d", len(buttons), maximumButtons)
+	}
+	return m.Payload(MessageCategoryAppButtonGroup, buttons)
+}
+
+func (m *MessageBuilder) Pin(messageIds ...string) *MessageBuilder {
+	return m.Payload(MessageCategoryMessagePin, &PinMessagePayload{Action: "PIN", MessageIds: messageIds})
+}
+
+func (m *MessageBuilder) Unpin(messageIds ...string) *MessageBuilder {
+	return m.Payload(MessageCategoryMessagePin, &PinMessagePayload{Action: "UNPIN", MessageIds: messageIds})
+}
+
+func (m *MessageBuilder) Recall(messageId string) *MessageBuilder {
+	return m.Payload(MessageCategoryMessageRecall, &RecallMessagePayload{MessageId: messageId})
+}
+
+// Payload sets the category and the JSON of payload as the data, for the
+// categories without a dedicated method.
+func (m *MessageBuilder) Payload(category string, payload any) *MessageBuilder {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		m.err = err
+	}
+	return m.raw(category, data)
+}
+
+func (m *MessageBuilder) raw(category string, data []byte) *MessageBuilder {
+	m.msg.Category = category
+	m.msg.DataBase64 = base64.RawURLEncoding.EncodeToString(data)
+	m.transcript = nil
+	return m
+}
+
+func (m *MessageBuilder) Build() (*MessageRequest, error) {
+	if m.err != nil {
+		return nil, m.err
+	}
+	if m.msg.Category == "" {
+		return nil, fmt.Errorf("message %s without content", m.msg.MessageId)
+	}
+	if m.msg.ConversationId == "" && m.msg.RecipientId == "" {
+		return nil, fmt.Errorf("message %s without conversation", m.msg.MessageId)
+	}
+	msg := m.msg
+	if m.transcript != nil {
+		messages := make([]TranscriptMessage, len(m.transcript))
+		for i, t := range m.transcript {
+			messages[i] = *t
+			messages[i].TranscriptId = msg.MessageId
+		}
+		data, err := json.Marshal(messages)
+		if err != nil {
+			return nil, err
+		}
+		msg.DataBase64 = base64.RawURLEncoding.EncodeToString(data)
+	}
+	return &msg, nil
+}
+
+// SendMessageRequest sends msg over Blaze like PostMessageRequest.
+func (b *BlazeClient) SendMessageRequest(ctx context.Context, msg *MessageRequest) error {
+	return b.createMessage(ctx, msg)
+}
+
+func (b *BlazeClient) sendBuilder(ctx context.Context, m *MessageBuilder) error {
+	msg, err := m.Build()
+	if err != nil {
+		return err
+	}
+	return b.createMessage(ctx, msg)
+}
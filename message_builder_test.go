@@ -0,0 +1,50 @@
+package bot
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageBuilder(t *testing.T) {
+	assert := assert.New(t)
+
+	msg, err := NewMessage("c", "u").MessageId("m").Quote("q").Silent(true).AppCard(&AppCardView{
+		AppID:     "a",
+		Title:     "Pay",
+		Actions:   []AppCardAction{{Label: "Open", Action: "https://mixin.one", Color: "#000"}},
+		Shareable: false,
+	}).Build()
+	assert.Nil(err)
+	assert.Equal("m", msg.MessageId)
+	assert.Equal("q", msg.QuoteMessageId)
+	assert.True(msg.Silent)
+	p, err := MessageView{Category: msg.Category, DataBase64: msg.DataBase64}.Decode()
+	assert.Nil(err)
+	assert.Equal("Open", p.AppCard.Actions[0].Label)
+	assert.False(p.AppCard.Shareable)
+
+	msg, err = NewMessage("c", "u").AppCard(&AppCardView{Title: "Pay", Description: "d"}).Build()
+	assert.Nil(err)
+	data, _ := messageData(MessageView{DataBase64: msg.DataBase64})
+	assert.Equal(`{"title":"Pay","description":"d","shareable":false}`, string(data))
+
+	transcript := []*TranscriptMessage{{MessageId: "x", Content: "hi"}}
+	msg, err = NewMessage("c", "u").Transcript(transcript).MessageId("t").Build()
+	assert.Nil(err)
+	p, err = MessageView{Category: msg.Category, DataBase64: msg.DataBase64}.Decode()
+	assert.Nil(err)
+	assert.Equal("t", p.Transcript[0].TranscriptId)
+	assert.Equal("hi", p.Transcript[0].Content)
+	assert.Equal("", transcript[0].TranscriptId)
+
+	msg, err = NewMessage("c", "u").Unpin("x", "y").Build()
+	assert.Nil(err)
+	p, _ = MessageView{Category: msg.Category, DataBase64: msg.DataBase64}.Decode()
+	assert.Equal(&PinMessagePayload{Action: "UNPIN", MessageIds: []string{"x", "y"}}, p.Pin)
+
+	_, err = NewMessage("c", "u").Build()
+	assert.ErrorContains(err, "without content")
+	_, err = NewMessage("c", "u").Buttons().Build()
+	assert.ErrorContains(err, "invalid buttons count 0")
+}
@@ -69,6 +69,18 @@ func CheckRetryableError(err error) bool {
 	if err == nil {
 		return false
 	}
+	switch {
+	case bot.IsServerError(err):
+	case bot.IsRateLimited(err):
+	case bot.IsInsufficientBalance(err):
+	case bot.IsUtxoLocked(err): // concurrent utxo query
+	default:
+		return checkRetryableReason(err)
+	}
+	return true
+}
+
+func checkRetryableReason(err error) bool {
 	reason := strings.ToLower(err.Error())
 	switch {
 	case strings.Contains(reason, "timeout"):
@@ -27,18 +27,22 @@ type MultisigUTXO struct {
 	SignedTx        string    `json:"signed_tx"`
 }
 
-func ReadMultisigsLegacy(ctx context.Context, limit int, offset string, user *SafeUser) ([]*MultisigUTXO, error) {
+func ReadMultisigsLegacy(ctx context.Context, limit int, offset string, auth Authorizer) ([]*MultisigUTXO, error) {
+	return defaultClient.WithAuthorizer(auth).ReadMultisigsLegacy(ctx, limit, offset)
+}
+
+func (c *Client) ReadMultisigsLegacy(ctx context.Context, limit int, offset string) ([]*MultisigUTXO, error) {
 	v := url.Values{}
 	v.Set("limit", fmt.Sprint(limit))
 	if offset != "" {
 		v.Set("offset", offset)
 	}
 	method, path := "GET", "/multisigs?"+v.Encode()
-	token, err := SignAuthenticationToken(method, path, "", user)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, nil, token)
+	body, err := c.Request(ctx, method, path, nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -57,7 +61,12 @@ func ReadMultisigsLegacy(ctx context.Context, limit int, offset string, user *Sa
 }
 
 // state: spent, unspent, signed
-func ReadMultisigs(ctx context.Context, limit int, offset, membersHash, threshold, state string, su *SafeUser) ([]*MultisigUTXO, error) {
+func ReadMultisigs(ctx context.Context, limit int, offset, membersHash, threshold, state string, auth Authorizer) ([]*MultisigUTXO, error) {
+	return defaultClient.WithAuthorizer(auth).ReadMultisigs(ctx, limit, offset, membersHash, threshold, state)
+}
+
+// state: spent, unspent, signed
+func (c *Client) ReadMultisigs(ctx context.Context, limit int, offset, membersHash, threshold, state string) ([]*MultisigUTXO, error) {
 	v := url.Values{}
 	v.Set("limit", fmt.Sprint(limit))
 	if offset != "" {
@@ -67,11 +76,11 @@ func ReadMultisigs(ctx context.Context, limit int, offset, membersHash, threshol
 	v.Set("threshold", threshold)
 	v.Set("state", state)
 	method, path := "GET", "/multisigs/outputs?"+v.Encode()
-	token, err := SignAuthenticationToken(method, path, "", su)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, nil, token)
+	body, err := c.Request(ctx, method, path, nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -109,7 +118,12 @@ type MultisigRequest struct {
 }
 
 // CreateMultisig create a multisigs request which action is `unlock` or `sign`
-func CreateMultisig(ctx context.Context, action, raw string, su *SafeUser) (*MultisigRequest, error) {
+func CreateMultisig(ctx context.Context, action, raw string, auth Authorizer) (*MultisigRequest, error) {
+	return defaultClient.WithAuthorizer(auth).CreateMultisig(ctx, action, raw)
+}
+
+// CreateMultisig create a multisigs request which action is `unlock` or `sign`
+func (c *Client) CreateMultisig(ctx context.Context, action, raw string) (*MultisigRequest, error) {
 	data, err := json.Marshal(map[string]string{
 		"action": action,
 		"raw":    raw,
@@ -118,11 +132,11 @@ func CreateMultisig(ctx context.Context, action, raw string, su *SafeUser) (*Mul
 		return nil, err
 	}
 	method, path := "POST", "/multisigs/requests"
-	token, err := SignAuthenticationToken(method, path, string(data), su)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -140,7 +154,11 @@ func CreateMultisig(ctx context.Context, action, raw string, su *SafeUser) (*Mul
 	return resp.Data, nil
 }
 
-func SignMultisig(ctx context.Context, id, pin string, su *SafeUser) (*MultisigRequest, error) {
+func SignMultisig(ctx context.Context, id, pin string, auth Authorizer) (*MultisigRequest, error) {
+	return defaultClient.WithAuthorizer(auth).SignMultisig(ctx, id, pin)
+}
+
+func (c *Client) SignMultisig(ctx context.Context, id, pin string) (*MultisigRequest, error) {
 	data, err := json.Marshal(map[string]string{
 		"pin_base64": pin,
 	})
@@ -148,11 +166,11 @@ func SignMultisig(ctx context.Context, id, pin string, su *SafeUser) (*MultisigR
 		return nil, err
 	}
 	method, path := "POST", "/multisigs/requests/"+id+"/sign"
-	token, err := SignAuthenticationToken(method, path, string(data), su)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -170,13 +188,17 @@ func SignMultisig(ctx context.Context, id, pin string, su *SafeUser) (*MultisigR
 	return resp.Data, nil
 }
 
-func CancelMultisig(ctx context.Context, id string, su *SafeUser) error {
+func CancelMultisig(ctx context.Context, id string, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).CancelMultisig(ctx, id)
+}
+
+func (c *Client) CancelMultisig(ctx context.Context, id string) error {
 	method, path := "POST", "/multisigs/requests/"+id+"/cancel"
-	token, err := SignAuthenticationToken(method, path, "", su)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, method, path, nil, token)
+	body, err := c.Request(ctx, method, path, nil, token)
 	if err != nil {
 		return ServerError(ctx, err)
 	}
@@ -193,7 +215,11 @@ func CancelMultisig(ctx context.Context, id string, su *SafeUser) error {
 	return nil
 }
 
-func UnlockMultisig(ctx context.Context, id, pin string, su *SafeUser) error {
+func UnlockMultisig(ctx context.Context, id, pin string, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).UnlockMultisig(ctx, id, pin)
+}
+
+func (c *Client) UnlockMultisig(ctx context.Context, id, pin string) error {
 	data, err := json.Marshal(map[string]string{
 		"pin_base64": pin,
 	})
@@ -201,11 +227,11 @@ func UnlockMultisig(ctx context.Context, id, pin string, su *SafeUser) error {
 		return err
 	}
 	method, path := "POST", "/multisigs/requests/"+id+"/unlock"
-	token, err := SignAuthenticationToken(method, path, string(data), su)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return ServerError(ctx, err)
 	}
@@ -39,7 +39,11 @@ type AssetNetwork struct {
 }
 
 func ReadNetworkAssets(ctx context.Context) ([]*AssetNetwork, error) {
-	body, err := Request(ctx, "GET", "/network", nil, "")
+	return defaultClient.ReadNetworkAssets(ctx)
+}
+
+func (c *Client) ReadNetworkAssets(ctx context.Context) ([]*AssetNetwork, error) {
+	body, err := c.Request(ctx, "GET", "/network", nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -60,7 +64,11 @@ func ReadNetworkAssets(ctx context.Context) ([]*AssetNetwork, error) {
 }
 
 func ReadNetworkAssetsTop(ctx context.Context) ([]*AssetNetwork, error) {
-	body, err := Request(ctx, "GET", "/network/assets/top", nil, "")
+	return defaultClient.ReadNetworkAssetsTop(ctx)
+}
+
+func (c *Client) ReadNetworkAssetsTop(ctx context.Context) ([]*AssetNetwork, error) {
+	body, err := c.Request(ctx, "GET", "/network/assets/top", nil, "")
 	if err != nil {
 		return nil, err
 	}
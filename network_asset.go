@@ -6,7 +6,11 @@ import (
 )
 
 func ReadAsset(ctx context.Context, id string) (*AssetNetwork, error) {
-	body, err := Request(ctx, "GET", "/network/assets/"+id, nil, "")
+	return defaultClient.ReadAsset(ctx, id)
+}
+
+func (c *Client) ReadAsset(ctx context.Context, id string) (*AssetNetwork, error) {
+	body, err := c.Request(ctx, "GET", "/network/assets/"+id, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -25,7 +29,11 @@ func ReadAsset(ctx context.Context, id string) (*AssetNetwork, error) {
 }
 
 func ReadAssetTickerWithOffset(ctx context.Context, id string, offset string) (*AssetTicker, error) {
-	body, err := Request(ctx, "GET", "/network/ticker?asset="+id+"&offset="+offset, nil, "")
+	return defaultClient.ReadAssetTickerWithOffset(ctx, id, offset)
+}
+
+func (c *Client) ReadAssetTickerWithOffset(ctx context.Context, id, offset string) (*AssetTicker, error) {
+	body, err := c.Request(ctx, "GET", "/network/ticker?asset="+id+"&offset="+offset, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -44,7 +52,11 @@ func ReadAssetTickerWithOffset(ctx context.Context, id string, offset string) (*
 }
 
 func ReadAssetTicker(ctx context.Context, id string) (*AssetTicker, error) {
-	body, err := Request(ctx, "GET", "/network/ticker?asset="+id, nil, "")
+	return defaultClient.ReadAssetTicker(ctx, id)
+}
+
+func (c *Client) ReadAssetTicker(ctx context.Context, id string) (*AssetTicker, error) {
+	body, err := c.Request(ctx, "GET", "/network/ticker?asset="+id, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +75,11 @@ func ReadAssetTicker(ctx context.Context, id string) (*AssetTicker, error) {
 }
 
 func AssetSearch(ctx context.Context, name string) ([]*AssetNetwork, error) {
-	body, err := Request(ctx, "GET", "/network/assets/search/"+name, nil, "")
+	return defaultClient.AssetSearch(ctx, name)
+}
+
+func (c *Client) AssetSearch(ctx context.Context, name string) ([]*AssetNetwork, error) {
+	body, err := c.Request(ctx, "GET", "/network/assets/search/"+name, nil, "")
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,269 @@
+package bot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+const (
+	oauthSubprotocol = "Mixin-OAuth-1"
+
+	OAuthStatusPending    = "pending"
+	OAuthStatusAuthorized = "authorized"
+	OAuthStatusFailed     = "failed"
+
+	// oauthPlaceholderCodeSize is the longest authorization code returned
+	// before the user authorizes it, an authorized code is always longer.
+	oauthPlaceholderCodeSize = 16
+)
+
+// OAuthAuthorization is the reply of REFRESH_OAUTH_CODE, AuthorizationCode is
+// empty until the user authorizes the code.
+type OAuthAuthorization struct {
+	AuthorizationId   string    `json:"authorization_id"`
+	AuthorizationCode string    `json:"authorization_code"`
+	CodeId            string    `json:"code_id"`
+	Scopes            []string  `json:"scopes"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// OAuthUpdate is a status of the session, URL is the mixin://codes scheme of
+// the current code to show as a QR code, and Code is set once authorized.
+type OAuthUpdate struct {
+	Status          string
+	CodeId          string
+	URL             string
+	AuthorizationId string
+	Code            string
+	Err             error
+}
+
+// OAuthToken signs the requests of the user locally with PrivateKey, the
+// server keeps its public key for the authorization.
+type OAuthToken struct {
+	ClientId        string
+	AuthorizationId string
+	Scope           string
+	PrivateKey      string // hex ed25519 seed
+	ServerPublicKey string // hex ed25519 public key
+}
+
+// OAuthSession logs a user in with Mixin, it requests a code for ClientId and
+// Scopes over the Mixin-OAuth-1 websocket, waits for the user to authorize it,
+// then exchanges the code with the PKCE verifier and a new ed25519 key.
+type OAuthSession struct {
+	ClientId string
+	Scopes   []string
+	// Interval is the delay between the code refreshes, 1 second by default.
+	Interval time.Duration
+
+	client   *Client
+	dial     BlazeDialer
+	verifier string
+	key      ed25519.PrivateKey
+	mutex    sync.Mutex
+	code     string
+}
+
+func NewOAuthSession(clientId string, scopes ...string) (*OAuthSession, error) {
+	return defaultClient.NewOAuthSession(clientId, scopes...)
+}
+
+func (c *Client) NewOAuthSession(clientId string, scopes ...string) (*OAuthSession, error) {
+	verifier := make([]byte, 32)
+	if _, err := rand.Read(verifier); err != nil {
+		return nil, err
+	}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &OAuthSession{
+		ClientId: clientId,
+		Scopes:   scopes,
+		Interval: time.Second,
+		client:   c,
+		dial:     coderDialer(nil, oauthSubprotocol),
+		verifier: base64.RawURLEncoding.EncodeToString(verifier),
+		key:      key,
+	}, nil
+}
+
+// SetDialOptions makes the session connect with coder/websocket and opts,
+// e.g. with an HTTP client using a proxy.
+func (s *OAuthSession) SetDialOptions(opts *websocket.DialOptions) {
+	s.dial = coderDialer(opts, oauthSubprotocol)
+}
+
+// SetTransport makes the session connect with dial, which should request the
+// Mixin-OAuth-1 subprotocol.
+func (s *OAuthSession) SetTransport(dial BlazeDialer) {
+	s.dial = dial
+}
+
+// CodeChallenge is the S256 PKCE challenge of the verifier of the session.
+func (s *OAuthSession) CodeChallenge() string {
+	sum := sha256.Sum256([]byte(s.verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Authorize requests codes until the user authorizes one or ctx is done. The
+// returned channel has a pending update for each new code, then one
+// authorized or failed update before it's closed. The channel is closed
+// without the last update when ctx is done and the caller stopped reading.
+func (s *OAuthSession) Authorize(ctx context.Context) <-chan OAuthUpdate {
+	updates := make(chan OAuthUpdate, 1)
+	go func() {
+		defer close(updates)
+		update := s.authorize(ctx, updates)
+		if update.Status == OAuthStatusAuthorized {
+			s.mutex.Lock()
+			s.code = update.Code
+			s.mutex.Unlock()
+		}
+		select {
+		case updates <- update:
+		case <-ctx.Done():
+		}
+	}()
+	return updates
+}
+
+func (s *OAuthSession) authorize(ctx context.Context, updates chan<- OAuthUpdate) OAuthUpdate {
+	failed := func(err error) OAuthUpdate {
+		return OAuthUpdate{Status: OAuthStatusFailed, Err: err}
+	}
+	u := url.URL{Scheme: "wss", Host: s.client.blazeUri, Path: "/"}
+	conn, err := s.dial(ctx, u.String(), make(http.Header))
+	if err != nil {
+		return failed(err)
+	}
+	defer conn.Close()
+
+	var authorizationId, codeId string
+	for {
+		auth, err := s.refresh(ctx, conn, authorizationId)
+		if err != nil {
+			return failed(err)
+		}
+		authorizationId = auth.AuthorizationId
+		update := OAuthUpdate{
+			Status:          OAuthStatusPending,
+			CodeId:          auth.CodeId,
+			URL:             SchemeCodes(auth.CodeId),
+			AuthorizationId: auth.AuthorizationId,
+		}
+		if len(auth.AuthorizationCode) > oauthPlaceholderCodeSize {
+			update.Status, update.Code = OAuthStatusAuthorized, auth.AuthorizationCode
+			return update
+		}
+		if auth.CodeId != codeId {
+			codeId = auth.CodeId
+			select {
+			case updates <- update:
+			case <-ctx.Done():
+				return failed(ctx.Err())
+			}
+		}
+		if err := sleepContext(ctx, s.Interval); err != nil {
+			return failed(err)
+		}
+	}
+}
+
+func (s *OAuthSession) refresh(ctx context.Context, conn BlazeTransport, authorizationId string) (*OAuthAuthorization, error) {
+	id := UuidNewV4().String()
+	msg, err := json.Marshal(BlazeMessage{Id: id, Action: "REFRESH_OAUTH_CODE", Params: map[string]any{
+		"client_id":        s.ClientId,
+		"scope":            strings.Join(s.Scopes, " "),
+		"authorization_id": authorizationId,
+		"code_challenge":   s.CodeChallenge(),
+	}})
+	if err != nil {
+		return nil, err
+	}
+	if err := writeGzipToConn(ctx, conn, msg); err != nil {
+		return nil, err
+	}
+	for {
+		reply, err := readBlazeMessage(ctx, conn)
+		if err != nil {
+			return nil, err
+		}
+		if reply.Id != id {
+			continue
+		}
+		if reply.Error != nil {
+			return nil, *reply.Error
+		}
+		var auth OAuthAuthorization
+		if err := json.Unmarshal(reply.Data, &auth); err != nil {
+			return nil, fmt.Errorf("invalid REFRESH_OAUTH_CODE reply %s", reply.Data)
+		}
+		return &auth, nil
+	}
+}
+
+// Exchange exchanges the authorized code for a token, which signs requests
+// with the ed25519 key of the session.
+func (s *OAuthSession) Exchange(ctx context.Context, clientSecret string) (*OAuthToken, error) {
+	s.mutex.Lock()
+	code := s.code
+	s.mutex.Unlock()
+	if code == "" {
+		return nil, fmt.Errorf("oauth session not authorized")
+	}
+	public := base64.RawURLEncoding.EncodeToString(s.key.Public().(ed25519.PublicKey))
+	serverKey, scope, authorizationId, err := s.client.OAuthGetAccessToken(ctx, s.ClientId, clientSecret, code, s.verifier, public)
+	if err != nil {
+		return nil, err
+	}
+	serverPub, err := base64.RawURLEncoding.DecodeString(serverKey)
+	if err != nil || len(serverPub) != ed25519.PublicKeySize {
+		return nil, BadDataError(ctx)
+	}
+	return &OAuthToken{
+		ClientId:        s.ClientId,
+		AuthorizationId: authorizationId,
+		Scope:           scope,
+		PrivateKey:      hex.EncodeToString(s.key.Seed()),
+		ServerPublicKey: hex.EncodeToString(serverPub),
+	}, nil
+}
+
+func readBlazeMessage(ctx context.Context, conn BlazeTransport) (*BlazeMessage, error) {
+	data, err := conn.ReadMessage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err = io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	var msg BlazeMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
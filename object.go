@@ -9,6 +9,10 @@ import (
 )
 
 func CreateObjectStorageTransaction(ctx context.Context, recipients []*TransactionRecipient, utxos []*Output, extra []byte, traceId string, references []string, limit string, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).CreateObjectStorageTransaction(ctx, recipients, utxos, extra, traceId, references, limit)
+}
+
+func (c *Client) CreateObjectStorageTransaction(ctx context.Context, recipients []*TransactionRecipient, utxos []*Output, extra []byte, traceId string, references []string, limit string) (*SequencerTransactionRequest, error) {
 	if len(extra) > common.ExtraSizeStorageCapacity {
 		return nil, fmt.Errorf("too large extra %d > %d", len(extra), common.ExtraSizeStorageCapacity)
 	}
@@ -28,9 +32,9 @@ func CreateObjectStorageTransaction(ctx context.Context, recipients []*Transacti
 		rec = append(rec, recipients...)
 	}
 	if len(utxos) > 0 {
-		return SendTransactionWithOutputs(ctx, common.XINAssetId.String(), rec, utxos, traceId, extra, references, u)
+		return c.SendTransactionWithOutputs(ctx, common.XINAssetId.String(), rec, utxos, traceId, extra, references)
 	}
-	return SendTransaction(ctx, common.XINAssetId.String(), rec, traceId, extra, references, u)
+	return c.SendTransaction(ctx, common.XINAssetId.String(), rec, traceId, extra, references)
 }
 
 func EstimateStorageCost(extra []byte) common.Integer {
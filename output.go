@@ -47,11 +47,19 @@ type Output struct {
 	RequestId       string `json:"request_id,omitempty"`
 }
 
-func ListUnspentOutputs(ctx context.Context, membersHash string, threshold byte, assetId string, u *SafeUser) ([]*Output, error) {
-	return ListOutputs(ctx, membersHash, threshold, assetId, OutputStateUnspent, 0, 500, u)
+func ListUnspentOutputs(ctx context.Context, membersHash string, threshold byte, assetId string, auth Authorizer) ([]*Output, error) {
+	return defaultClient.WithAuthorizer(auth).ListUnspentOutputs(ctx, membersHash, threshold, assetId)
 }
 
-func ListOutputs(ctx context.Context, membersHash string, threshold byte, assetId, state string, offsetSequence int64, limit int, u *SafeUser) ([]*Output, error) {
+func (c *Client) ListUnspentOutputs(ctx context.Context, membersHash string, threshold byte, assetId string) ([]*Output, error) {
+	return c.ListOutputs(ctx, membersHash, threshold, assetId, OutputStateUnspent, 0, 500)
+}
+
+func ListOutputs(ctx context.Context, membersHash string, threshold byte, assetId, state string, offsetSequence int64, limit int, auth Authorizer) ([]*Output, error) {
+	return defaultClient.WithAuthorizer(auth).ListOutputs(ctx, membersHash, threshold, assetId, state, offsetSequence, limit)
+}
+
+func (c *Client) ListOutputs(ctx context.Context, membersHash string, threshold byte, assetId, state string, offsetSequence int64, limit int) ([]*Output, error) {
 	v := url.Values{}
 	v.Set("members", membersHash)
 	v.Set("threshold", fmt.Sprint(threshold))
@@ -66,11 +74,11 @@ func ListOutputs(ctx context.Context, membersHash string, threshold byte, assetI
 		v.Set("state", state)
 	}
 	method, path := "GET", fmt.Sprintf("/safe/outputs?%s", v.Encode())
-	token, err := SignAuthenticationToken(method, path, "", u)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, []byte{}, token)
+	body, err := c.Request(ctx, method, path, []byte{}, token)
 	if err != nil {
 		return nil, err
 	}
@@ -88,50 +96,27 @@ func ListOutputs(ctx context.Context, membersHash string, threshold byte, assetI
 	return resp.Data, nil
 }
 
+// Deprecated: use ListUnspentOutputs with BearerToken(accessToken).
 func ListUnspentOutputsByToken(ctx context.Context, membersHash string, threshold byte, assetId string, accessToken string) ([]*Output, error) {
-	return ListOutputsByToken(ctx, membersHash, threshold, assetId, OutputStateUnspent, 0, 500, accessToken)
+	return ListUnspentOutputs(ctx, membersHash, threshold, assetId, BearerToken(accessToken))
 }
 
+// Deprecated: use ListOutputs with BearerToken(accessToken).
 func ListOutputsByToken(ctx context.Context, membersHash string, threshold byte, assetId, state string, offset int64, limit int, accessToken string) ([]*Output, error) {
-	v := url.Values{}
-	v.Set("members", membersHash)
-	v.Set("threshold", fmt.Sprint(threshold))
-	v.Set("limit", strconv.Itoa(limit))
-	if offset > 0 {
-		v.Set("offset", fmt.Sprint(offset))
-	}
-	if assetId != "" {
-		v.Set("asset", assetId)
-	}
-	if state != "" {
-		v.Set("state", state)
-	}
-	method, path := "GET", fmt.Sprintf("/safe/outputs?%s", v.Encode())
-	body, err := Request(ctx, method, path, []byte{}, accessToken)
-	if err != nil {
-		return nil, err
-	}
-	var resp struct {
-		Data  []*Output `json:"data"`
-		Error Error     `json:"error"`
-	}
-	err = json.Unmarshal(body, &resp)
-	if err != nil {
-		return nil, BadDataError(ctx)
-	}
-	if resp.Error.Code > 0 {
-		return nil, resp.Error
-	}
-	return resp.Data, nil
+	return ListOutputs(ctx, membersHash, threshold, assetId, state, offset, limit, BearerToken(accessToken))
+}
+
+func GetOutput(ctx context.Context, id string, auth Authorizer) (*Output, error) {
+	return defaultClient.WithAuthorizer(auth).GetOutput(ctx, id)
 }
 
-func GetOutput(ctx context.Context, id string, u *SafeUser) (*Output, error) {
+func (c *Client) GetOutput(ctx context.Context, id string) (*Output, error) {
 	method, path := "GET", fmt.Sprintf("/safe/outputs/%s", id)
-	token, err := SignAuthenticationToken(method, path, "", u)
+	token, err := c.sign(method, path, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, []byte{}, token)
+	body, err := c.Request(ctx, method, path, []byte{}, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -0,0 +1,166 @@
+package bot
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type StickerMessagePayload struct {
+	StickerId string `json:"sticker_id"`
+	AlbumId   string `json:"album_id,omitempty"`
+	Name      string `json:"name,omitempty"`
+}
+
+type ContactMessagePayload struct {
+	UserId string `json:"user_id"`
+}
+
+type LocationMessagePayload struct {
+	Longitude float64 `json:"longitude"`
+	Latitude  float64 `json:"latitude"`
+	Name      string  `json:"name,omitempty"`
+	Address   string  `json:"address,omitempty"`
+}
+
+type DataMessagePayload struct {
+	AttachmentId string `json:"attachment_id"`
+	MimeType     string `json:"mime_type"`
+	Size         int64  `json:"size"`
+	Name         string `json:"name"`
+	Key          []byte `json:"key,omitempty"`
+	Digest       []byte `json:"digest,omitempty"`
+}
+
+type PinMessagePayload struct {
+	Action     string   `json:"action"` // PIN or UNPIN
+	MessageIds []string `json:"message_ids"`
+}
+
+type TranscriptMessage struct {
+	TranscriptId  string    `json:"transcript_id"`
+	MessageId     string    `json:"message_id"`
+	UserId        string    `json:"user_id"`
+	UserFullName  string    `json:"user_full_name"`
+	Category      string    `json:"category"`
+	Content       string    `json:"content,omitempty"`
+	MediaUrl      string    `json:"media_url,omitempty"`
+	MediaName     string    `json:"media_name,omitempty"`
+	MediaSize     int64     `json:"media_size,omitempty"`
+	MediaWidth    int       `json:"media_width,omitempty"`
+	MediaHeight   int       `json:"media_height,omitempty"`
+	MediaMimeType string    `json:"media_mime_type,omitempty"`
+	MediaDuration int64     `json:"media_duration,omitempty"`
+	ThumbImage    string    `json:"thumb_image,omitempty"`
+	ThumbUrl      string    `json:"thumb_url,omitempty"`
+	StickerId     string    `json:"sticker_id,omitempty"`
+	SharedUserId  string    `json:"shared_user_id,omitempty"`
+	QuoteId       string    `json:"quote_id,omitempty"`
+	QuoteContent  string    `json:"quote_content,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// MessagePayload is the decoded data of a message, only the field matching
+// Category is set, and Raw always keeps the decoded bytes.
+type MessagePayload struct {
+	Category string
+	Raw      []byte
+
+	Text               string                     // PLAIN_TEXT and PLAIN_POST
+	Image              *ImageMessagePayload       // PLAIN_IMAGE
+	Data               *DataMessagePayload        // PLAIN_DATA
+	Audio              *AudioMessagePayload       // PLAIN_AUDIO
+	Video              *VideoMessagePayload       // PLAIN_VIDEO
+	Sticker            *StickerMessagePayload     // PLAIN_STICKER
+	Live               *LiveMessagePayload        // PLAIN_LIVE
+	Contact            *ContactMessagePayload     // PLAIN_CONTACT
+	Location           *LocationMessagePayload    // PLAIN_LOCATION
+	Transcript         []*TranscriptMessage       // PLAIN_TRANSCRIPT
+	SystemConversation *SystemConversationPayload // SYSTEM_CONVERSATION
+	AccountSnapshot    *TransferView              // SYSTEM_ACCOUNT_SNAPSHOT
+	SafeSnapshot       *TransferSafeView          // SYSTEM_SAFE_SNAPSHOT and SYSTEM_SAFE_INSCRIPTION
+	Recall             *RecallMessagePayload      // MESSAGE_RECALL
+	Pin                *PinMessagePayload         // MESSAGE_PIN
+	Buttons            []*AppButtonView           // APP_BUTTON_GROUP
+	AppCard            *AppCardView               // APP_CARD
+}
+
+// Decode decodes the data of the message by its category, the payload of an
+// unknown category only has Raw.
+func (m MessageView) Decode() (*MessagePayload, error) {
+	data, err := messageData(m)
+	if err != nil {
+		return nil, err
+	}
+	p := &MessagePayload{Category: m.Category, Raw: data}
+	var v any
+	switch m.Category {
+	case MessageCategoryPlainText, MessageCategoryPlainPost:
+		p.Text = string(data)
+	case MessageCategoryPlainImage:
+		p.Image = &ImageMessagePayload{}
+		v = p.Image
+	case MessageCategoryPlainData:
+		p.Data = &DataMessagePayload{}
+		v = p.Data
+	case MessageCategoryPlainAudio:
+		p.Audio = &AudioMessagePayload{}
+		v = p.Audio
+	case MessageCategoryPlainVideo:
+		p.Video = &VideoMessagePayload{}
+		v = p.Video
+	case MessageCategoryPlainSticker:
+		p.Sticker = &StickerMessagePayload{}
+		v = p.Sticker
+	case MessageCategoryPlainLive:
+		p.Live = &LiveMessagePayload{}
+		v = p.Live
+	case MessageCategoryPlainContact:
+		p.Contact = &ContactMessagePayload{}
+		v = p.Contact
+	case MessageCategoryPlainLocation:
+		p.Location = &LocationMessagePayload{}
+		v = p.Location
+	case MessageCategoryPlainTranscript:
+		v = &p.Transcript
+	case MessageCategorySystemConversation:
+		p.SystemConversation = &SystemConversationPayload{}
+		v = p.SystemConversation
+	case MessageCategorySystemAccountSnapshot:
+		p.AccountSnapshot = &TransferView{}
+		v = p.AccountSnapshot
+	case MessageCategorySystemSafeSnapshot, MessageCategorySystemSafeInscription:
+		p.SafeSnapshot = &TransferSafeView{}
+		v = p.SafeSnapshot
+	case MessageCategoryMessageRecall:
+		p.Recall = &RecallMessagePayload{}
+		v = p.Recall
+	case MessageCategoryMessagePin:
+		p.Pin = &PinMessagePayload{}
+		v = p.Pin
+	case MessageCategoryAppButtonGroup:
+		v = &p.Buttons
+	case MessageCategoryAppCard:
+		p.AppCard = &AppCardView{}
+		v = p.AppCard
+	}
+	if v != nil {
+		if err := json.Unmarshal(data, v); err != nil {
+			return nil, fmt.Errorf("invalid %s payload %s %v", m.Category, m.MessageId, err)
+		}
+	}
+	return p, nil
+}
+
+// messageData decodes the data of msg, which is padded standard base64 from
+// the Blaze server, or raw url base64 from the bots.
+func messageData(msg MessageView) ([]byte, error) {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.RawURLEncoding, base64.URLEncoding, base64.RawStdEncoding} {
+		data, err := enc.DecodeString(msg.DataBase64)
+		if err == nil {
+			return data, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid message data %s %s", msg.MessageId, msg.Category)
+}
@@ -0,0 +1,54 @@
+package bot
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageViewDecode(t *testing.T) {
+	assert := assert.New(t)
+
+	msg := func(category, data string) MessageView {
+		return MessageView{MessageId: "m", Category: category, DataBase64: base64.StdEncoding.EncodeToString([]byte(data))}
+	}
+
+	p, err := msg(MessageCategoryPlainText, "hello").Decode()
+	assert.Nil(err)
+	assert.Equal("hello", p.Text)
+
+	p, err = msg(MessageCategoryPlainLocation, `{"longitude":121.5,"latitude":31.2,"name":"Bund"}`).Decode()
+	assert.Nil(err)
+	assert.Equal(&LocationMessagePayload{Longitude: 121.5, Latitude: 31.2, Name: "Bund"}, p.Location)
+
+	p, err = msg(MessageCategoryMessagePin, `{"action":"PIN","message_ids":["a","b"]}`).Decode()
+	assert.Nil(err)
+	assert.Equal([]string{"a", "b"}, p.Pin.MessageIds)
+
+	p, err = msg(MessageCategoryPlainTranscript, `[{"message_id":"a","category":"PLAIN_TEXT","content":"hi"}]`).Decode()
+	assert.Nil(err)
+	assert.Len(p.Transcript, 1)
+	assert.Equal("hi", p.Transcript[0].Content)
+
+	p, err = msg(MessageCategoryAppButtonGroup, `[{"label":"Go","action":"https://mixin.one","color":"#000"}]`).Decode()
+	assert.Nil(err)
+	assert.Equal("Go", p.Buttons[0].Label)
+
+	p, err = msg(MessageCategorySystemSafeSnapshot, `{"type":"snapshot","snapshot_id":"s","amount":"1"}`).Decode()
+	assert.Nil(err)
+	assert.Equal("s", p.SafeSnapshot.SnapshotId)
+
+	p, err = msg("PLAIN_UNKNOWN", `{"attachment_id":"x"}`).Decode()
+	assert.Nil(err)
+	assert.Equal("PLAIN_UNKNOWN", p.Category)
+	assert.Equal(`{"attachment_id":"x"}`, string(p.Raw))
+
+	raw := MessageView{Category: MessageCategoryPlainSticker, DataBase64: base64.RawURLEncoding.EncodeToString([]byte(`{"sticker_id":"st"}`))}
+	p, err = raw.Decode()
+	assert.Nil(err)
+	assert.Equal("st", p.Sticker.StickerId)
+
+	_, err = msg(MessageCategoryPlainContact, `not json`).Decode()
+	assert.ErrorContains(err, "invalid PLAIN_CONTACT payload")
+}
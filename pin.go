@@ -14,7 +14,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
 	"time"
 
 	"github.com/gofrs/uuid/v5"
@@ -79,7 +78,11 @@ func EncryptEd25519PIN(pin string, iterator uint64, current *SafeUser) (string,
 }
 
 func VerifyPIN(ctx context.Context, pin string, user *SafeUser) (*User, error) {
-	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), user)
+	return defaultClient.WithSafeUser(user).VerifyPIN(ctx, pin)
+}
+
+func (c *Client) VerifyPIN(ctx context.Context, pin string) (*User, error) {
+	encryptedPIN, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -90,11 +93,11 @@ func VerifyPIN(ctx context.Context, pin string, user *SafeUser) (*User, error) {
 		return nil, err
 	}
 	path := "/pin/verify"
-	token, err := SignAuthenticationToken("POST", path, string(data), user)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -113,14 +116,18 @@ func VerifyPIN(ctx context.Context, pin string, user *SafeUser) (*User, error) {
 }
 
 func VerifyPINTip(ctx context.Context, su *SafeUser) (*User, error) {
+	return defaultClient.WithSafeUser(su).VerifyPINTip(ctx)
+}
+
+func (c *Client) VerifyPINTip(ctx context.Context) (*User, error) {
 	TIPVerify := "TIP:VERIFY:"
 	timestamp := time.Now().UnixNano()
 	tb := fmt.Appendf(nil, "%s%032d", TIPVerify, timestamp)
-	pin, err := signTipBody(tb, su.SpendPrivateKey, su.IsSpendPrivateSum)
+	pin, err := signTipBody(tb, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		panic(err)
 	}
-	source, err := EncryptEd25519PIN(pin, uint64(timestamp), su)
+	source, err := EncryptEd25519PIN(pin, uint64(timestamp), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -132,13 +139,13 @@ func VerifyPINTip(ctx context.Context, su *SafeUser) (*User, error) {
 		return nil, err
 	}
 	path := "/pin/verify"
-	token, err := SignAuthenticationToken("POST", path, string(data), su)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
 	id := uuid.Must(uuid.NewV4()).String()
-	log.Println(id)
-	body, err := RequestWithId(ctx, "POST", path, data, token, id)
+	c.log().DebugContext(ctx, "verify pin", "path", path, "request_id", id)
+	body, err := c.RequestWithId(ctx, "POST", path, data, token, id)
 	if err != nil {
 		return nil, err
 	}
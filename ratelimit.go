@@ -0,0 +1,168 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a client side token bucket with a budget for each endpoint
+// group, e.g. /messages, /users or /safe/outputs. When the API still answers
+// 429, the request waits for Retry-After or a backoff and is sent again up to
+// MaxRetries times.
+type RateLimiter struct {
+	MaxRetries int
+
+	mutex   sync.Mutex
+	rate    float64
+	burst   int
+	limits  map[string]rateLimit
+	buckets map[string]*tokenBucket
+	stats   map[string]*RateLimitStats
+}
+
+type RateLimitStats struct {
+	Requests  uint64 `json:"requests"`
+	Delayed   uint64 `json:"delayed"`
+	Throttled uint64 `json:"throttled"`
+}
+
+type rateLimit struct {
+	rate  float64
+	burst int
+}
+
+type tokenBucket struct {
+	limit  rateLimit
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter allows rate requests per second with bursts of burst
+// requests for every endpoint group without an explicit limit.
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		MaxRetries: 3,
+		rate:       rate,
+		burst:      burst,
+		limits:     make(map[string]rateLimit),
+		buckets:    make(map[string]*tokenBucket),
+		stats:      make(map[string]*RateLimitStats),
+	}
+}
+
+func (l *RateLimiter) SetLimit(group string, rate float64, burst int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.limits[group] = rateLimit{rate: rate, burst: burst}
+	delete(l.buckets, group)
+}
+
+func (l *RateLimiter) Stats() map[string]RateLimitStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	stats := make(map[string]RateLimitStats, len(l.stats))
+	for g, s := range l.stats {
+		stats[g] = *s
+	}
+	return stats
+}
+
+// EndpointGroup maps a request path to its rate limit group, the first path
+// segment or the first two for the /safe, /network and /external namespaces.
+func EndpointGroup(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch parts[0] {
+	case "safe", "network", "external":
+		if len(parts) > 1 {
+			return "/" + parts[0] + "/" + parts[1]
+		}
+	}
+	return "/" + parts[0]
+}
+
+func (c *Client) SetRateLimiter(l *RateLimiter) {
+	c.limiter = l
+}
+
+func (l *RateLimiter) wait(ctx context.Context, path string) error {
+	if l == nil {
+		return nil
+	}
+	group := EndpointGroup(path)
+	l.mutex.Lock()
+	delay := l.bucket(group).reserve(time.Now())
+	stats := l.groupStats(group)
+	stats.Requests++
+	if delay > 0 {
+		stats.Delayed++
+	}
+	l.mutex.Unlock()
+	if delay <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, delay)
+}
+
+func (l *RateLimiter) shouldRetry(throttled int) bool {
+	return l != nil && throttled < l.MaxRetries
+}
+
+// throttle records a 429 response, drains the group bucket so concurrent
+// requests slow down too, and returns how long to wait before the retry.
+func (l *RateLimiter) throttle(path string, header http.Header, throttled int) time.Duration {
+	group := EndpointGroup(path)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.groupStats(group).Throttled++
+	b := l.bucket(group)
+	b.tokens = min(b.tokens, 0)
+
+	if s, err := strconv.Atoi(header.Get("Retry-After")); err == nil && s > 0 {
+		return time.Duration(s) * time.Second
+	}
+	delay := 500 * time.Millisecond << (throttled - 1)
+	return min(delay, 10*time.Second)
+}
+
+func (l *RateLimiter) bucket(group string) *tokenBucket {
+	b := l.buckets[group]
+	if b != nil {
+		return b
+	}
+	limit, found := l.limits[group]
+	if !found {
+		limit = rateLimit{rate: l.rate, burst: l.burst}
+	}
+	b = &tokenBucket{limit: limit, tokens: float64(limit.burst), last: time.Now()}
+	l.buckets[group] = b
+	return b
+}
+
+func (l *RateLimiter) groupStats(group string) *RateLimitStats {
+	s := l.stats[group]
+	if s == nil {
+		s = &RateLimitStats{}
+		l.stats[group] = s
+	}
+	return s
+}
+
+// reserve takes one token and returns how long the caller has to wait for it,
+// the balance may go negative so that waiting callers queue up fairly.
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	if b.limit.rate <= 0 {
+		return 0
+	}
+	b.tokens = min(b.tokens+now.Sub(b.last).Seconds()*b.limit.rate, float64(b.limit.burst))
+	b.last = now
+	b.tokens -= 1
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.limit.rate * float64(time.Second))
+}
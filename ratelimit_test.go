@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	assert.Equal("/messages", EndpointGroup("/messages"))
+	assert.Equal("/users", EndpointGroup("/users/fetch"))
+	assert.Equal("/safe/outputs", EndpointGroup("/safe/outputs?members=abc"))
+	assert.Equal("/network/snapshots", EndpointGroup("/network/snapshots/abc"))
+
+	now := time.Now()
+	b := &tokenBucket{limit: rateLimit{rate: 10, burst: 2}, tokens: 2, last: now}
+	assert.Equal(time.Duration(0), b.reserve(now))
+	assert.Equal(time.Duration(0), b.reserve(now))
+	assert.Equal(100*time.Millisecond, b.reserve(now))
+	assert.Equal(100*time.Millisecond, b.reserve(now.Add(100*time.Millisecond)))
+
+	var count int
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"status":429,"code":429,"description":"Too Many Requests"}}`))
+			return
+		}
+		w.Write([]byte(`{"data":[]}`))
+	}))
+	defer s.Close()
+
+	c := NewClient(nil)
+	c.SetBaseUri(s.URL)
+	l := NewRateLimiter(100, 10)
+	c.SetRateLimiter(l)
+	_, err := c.Request(ctx, "POST", "/messages", nil, "")
+	assert.Nil(err)
+	assert.Equal(2, count)
+	stats := l.Stats()["/messages"]
+	assert.Equal(uint64(2), stats.Requests)
+	assert.Equal(uint64(1), stats.Throttled)
+
+	// a throttled request is not an attempt of the retry policy
+	s.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		if count == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	count = 0
+	p := NewRetryPolicy(2)
+	p.MinBackoff = time.Millisecond
+	_, err = c.Request(WithRetryPolicy(ctx, p), "GET", "/users/me", nil, "")
+	assert.NotNil(err)
+	assert.Equal(3, count)
+}
@@ -3,113 +3,279 @@ package bot
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var (
 	DefaultApiHost   = "https://api.mixin.one"
 	DefaultBlazeHost = "blaze.mixin.one"
 
+	defaultClient *Client
+)
+
+// Client holds everything needed to talk to the Mixin API, so one process
+// can use several hosts or bot identities at the same time. The package
+// level functions are thin wrappers over a default client.
+type Client struct {
 	httpClient *http.Client
 	httpUri    string
 	blazeUri   string
-	userAgent  = "Bot-API-Go-Client"
-	debug      = false
+	userAgent  string
+	debug      bool
+	logger     *slog.Logger
+	user       *SafeUser
+	auth       Authorizer
+	retry      *RetryPolicy
+	limiter    *RateLimiter
+	telemetry  *telemetry
+	encrypt    bool
+	sessions   *sessionCache
+}
 
-	uid        string
-	sid        string
-	privateKey string
-)
+func NewClient(su *SafeUser) *Client {
+	return &Client{
+		httpClient: &http.Client{Timeout: 10 * time.Second, Transport: http.DefaultTransport},
+		httpUri:    DefaultApiHost,
+		blazeUri:   DefaultBlazeHost,
+		userAgent:  "Bot-API-Go-Client",
+		user:       su,
+		auth:       authorizer(su),
+		telemetry:  newTelemetry(nil, nil),
+		sessions:   newSessionCache(defaultSessionCacheTTL),
+	}
+}
 
-func Request(ctx context.Context, method, path string, body []byte, accessToken string) ([]byte, error) {
-	return RequestWithId(ctx, method, path, body, accessToken, UuidNewV4().String())
+// WithSafeUser returns a shallow copy of the client using su as the default
+// identity, the http client and other settings are shared with c.
+func (c *Client) WithSafeUser(su *SafeUser) *Client {
+	n := *c
+	n.user = su
+	n.auth = authorizer(su)
+	return &n
 }
 
-func RequestWithId(ctx context.Context, method, path string, body []byte, accessToken, requestID string) ([]byte, error) {
-	req, err := http.NewRequest(method, httpUri+path, bytes.NewReader(body))
+func (c *Client) SafeUser() *SafeUser {
+	return c.user
+}
+
+func (c *Client) SetHTTPClient(hc *http.Client) {
+	c.httpClient = hc
+}
+
+func (c *Client) SetBaseUri(base string) {
+	c.httpUri = base
+}
+
+func (c *Client) SetBlazeUri(blaze string) {
+	c.blazeUri = blaze
+}
+
+func (c *Client) SetUserAgent(ua string) {
+	c.userAgent = ua
+}
+
+func (c *Client) SetDebug(d bool) {
+	c.debug = d
+}
+
+// Request sends the request with a new X-Request-Id, which the retries of
+// the request keep, so the server can tell them from other requests. The
+// request is linked to the trace of ctx by the traceparent header and the
+// mixin.request_id attribute of its span.
+func (c *Client) Request(ctx context.Context, method, path string, body []byte, accessToken string) ([]byte, error) {
+	return c.RequestWithId(ctx, method, path, body, accessToken, UuidNewV4().String())
+}
+
+func (c *Client) RequestWithId(ctx context.Context, method, path string, body []byte, accessToken, requestID string) (_ []byte, err error) {
+	ctx, span := c.telemetry.start(ctx, method+" "+EndpointGroup(path), trace.SpanKindClient,
+		attribute.String("http.request.method", method),
+		attribute.String("url.path", path),
+		attribute.String("mixin.request_id", requestID))
+	defer func() { endSpan(span, err) }()
+
+	// the throttled requests are retried by the rate limiter, and don't count
+	// as attempts of the retry policy
+	policy := c.retryPolicy(ctx)
+	attempt, throttled := 1, 0
+	for {
+		if err := c.limiter.wait(ctx, path); err != nil {
+			return nil, err
+		}
+		span.SetAttributes(attribute.Int("mixin.attempts", attempt))
+		start := time.Now()
+		resp, err := c.doRequest(ctx, method, path, body, accessToken, requestID)
+		c.telemetry.recordRequest(ctx, method, path, start, resp, err)
+		c.logRequest(ctx, method, path, requestID, attempt, start, resp, err)
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.status))
+		}
+		if err == nil && resp.rateLimited() && c.limiter.shouldRetry(throttled) {
+			throttled++
+			span.SetAttributes(attribute.Int("mixin.throttled", throttled))
+			delay := c.limiter.throttle(path, resp.header, throttled)
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if !policy.shouldRetry(attempt, resp, err) {
+			if err != nil {
+				return nil, err
+			}
+			if resp.status >= 500 {
+				return nil, errors.Wrap(ServerError(ctx, nil), fmt.Sprintf("response status code %d %s", resp.status, requestID))
+			}
+			return resp.body, nil
+		}
+		if err := sleepContext(ctx, policy.backoff(attempt)); err != nil {
+			return nil, err
+		}
+		attempt++
+	}
+}
+
+type apiResponse struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// errorCode returns the code of the error object in the response body, the
+// body is only decoded for responses which are not server errors.
+func (r *apiResponse) errorCode() int {
+	if r == nil || r.status >= 500 {
+		return 0
+	}
+	var resp struct {
+		Error *Error `json:"error"`
+	}
+	if json.Unmarshal(r.body, &resp) != nil || resp.Error == nil {
+		return 0
+	}
+	return resp.Error.Code
+}
+
+func (r *apiResponse) rateLimited() bool {
+	return r.status == http.StatusTooManyRequests || r.errorCode() == http.StatusTooManyRequests
+}
+
+func (c *Client) doRequest(ctx context.Context, method, path string, body []byte, accessToken, requestID string) (*apiResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.httpUri+path, bytes.NewReader(body))
 	if err != nil {
 		return nil, err
 	}
-	if debug {
-		log.Printf("Request: %s , path: %s, requestId: %s", method, path, requestID)
-	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+accessToken)
 	req.Header.Set("X-Request-Id", requestID)
-	req.Header.Set("User-Agent", userAgent)
-	resp, err := httpClient.Do(req)
+	req.Header.Set("User-Agent", c.userAgent)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode >= 500 {
-		return nil, errors.Wrap(ServerError(ctx, nil), fmt.Sprintf("response status code %d %s", resp.StatusCode, requestID))
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
-	return io.ReadAll(resp.Body)
+	return &apiResponse{status: resp.StatusCode, header: resp.Header, body: data}, nil
 }
 
-func SimpleRequest(ctx context.Context, method, path string, body []byte) ([]byte, error) {
-	transport, err := NewTransport(
-		httpClient.Transport,
-		uid,
-		sid,
-		privateKey,
-	)
-	if err != nil {
-		return nil, err
+// logRequest logs every attempt at debug level, or at info level when the
+// client is in debug mode, transport errors and server errors are warnings.
+func (c *Client) logRequest(ctx context.Context, method, path, requestID string, attempt int, start time.Time, resp *apiResponse, err error) {
+	level := slog.LevelDebug
+	if c.debug {
+		level = slog.LevelInfo
+	}
+	if err != nil || resp.status >= 500 || resp.rateLimited() {
+		level = slog.LevelWarn
+	}
+	logger := c.log()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", path),
+		slog.String("request_id", requestID),
+		slog.Int("attempt", attempt),
+		slog.Duration("duration", time.Since(start)),
 	}
-	httpClient.Transport = transport
-	req, err := http.NewRequest(method, httpUri+path, bytes.NewReader(body))
 	if err != nil {
-		return nil, err
+		attrs = append(attrs, slog.Any("error", err))
+	} else {
+		attrs = append(attrs, slog.Int("status", resp.status))
 	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := httpClient.Do(req)
+	logger.LogAttrs(ctx, level, "mixin api request", attrs...)
+}
+
+// SimpleRequest signs the request with the authorizer of the client.
+func (c *Client) SimpleRequest(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	token, err := c.sign(method, path, string(body))
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	return c.Request(ctx, method, path, body, token)
+}
 
-	if resp.StatusCode >= 500 {
-		return nil, errors.Wrap(ServerError(ctx, nil), fmt.Sprintf("response status code %d", resp.StatusCode))
-	}
-	return io.ReadAll(resp.Body)
+func Request(ctx context.Context, method, path string, body []byte, accessToken string) ([]byte, error) {
+	return defaultClient.Request(ctx, method, path, body, accessToken)
+}
+
+func RequestWithId(ctx context.Context, method, path string, body []byte, accessToken, requestID string) ([]byte, error) {
+	return defaultClient.RequestWithId(ctx, method, path, body, accessToken, requestID)
+}
+
+func SimpleRequest(ctx context.Context, method, path string, body []byte) ([]byte, error) {
+	return defaultClient.SimpleRequest(ctx, method, path, body)
 }
 
 func init() {
-	httpClient = &http.Client{Timeout: 10 * time.Second}
-	httpUri = DefaultApiHost
-	blazeUri = DefaultBlazeHost
-	if httpClient.Transport == nil {
-		httpClient.Transport = http.DefaultTransport
-	}
+	defaultClient = NewClient(nil)
 }
 
 func WithAPIKey(userId, sessionId, p string) {
-	uid = userId
-	sid = sessionId
-	privateKey = p
+	defaultClient.user = &SafeUser{
+		UserId:            userId,
+		SessionId:         sessionId,
+		SessionPrivateKey: p,
+	}
+	defaultClient.auth = defaultClient.user
 }
 
 func SetBaseUri(base string) {
-	httpUri = base
+	defaultClient.SetBaseUri(base)
 }
 
 func SetBlazeUri(blaze string) {
-	blazeUri = blaze
+	defaultClient.SetBlazeUri(blaze)
 }
 
 func SetUserAgent(ua string) {
-	userAgent = ua
+	defaultClient.SetUserAgent(ua)
 }
 
 func SetDebug(d bool) {
-	debug = d
+	defaultClient.SetDebug(d)
+}
+
+func SetRetryPolicy(p *RetryPolicy) {
+	defaultClient.SetRetryPolicy(p)
+}
+
+func SetRateLimiter(l *RateLimiter) {
+	defaultClient.SetRateLimiter(l)
 }
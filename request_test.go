@@ -0,0 +1,49 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientInstances(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var agents []string
+	handler := func(id string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			agents = append(agents, r.Header.Get("User-Agent"))
+			w.Write([]byte(`{"data":{"type":"user","user_id":"` + id + `"}}`))
+		}
+	}
+	s1 := httptest.NewServer(handler("a"))
+	defer s1.Close()
+	s2 := httptest.NewServer(handler("b"))
+	defer s2.Close()
+
+	c1 := NewClient(nil)
+	c1.SetBaseUri(s1.URL)
+	c1.SetUserAgent("client-1")
+	c2 := NewClient(nil)
+	c2.SetBaseUri(s2.URL)
+	c2.SetUserAgent("client-2")
+
+	me, err := c1.UserMe(ctx, "token")
+	assert.Nil(err)
+	assert.Equal("a", me.UserId)
+	me, err = c2.UserMe(ctx, "token")
+	assert.Nil(err)
+	assert.Equal("b", me.UserId)
+	assert.Equal([]string{"client-1", "client-2"}, agents)
+
+	su := &SafeUser{UserId: "u"}
+	c3 := c1.WithSafeUser(su)
+	assert.Equal(su, c3.SafeUser())
+	assert.Nil(c1.SafeUser())
+	_, err = c1.SimpleRequest(ctx, "GET", "/me", nil)
+	assert.NotNil(err)
+}
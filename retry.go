@@ -0,0 +1,102 @@
+package bot
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides whether and when a failed API call is sent again.
+// All attempts share the same X-Request-Id, so retried writes such as
+// POST /safe/transactions or /messages stay idempotent on the server.
+type RetryPolicy struct {
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+	// RetryOn receives the HTTP status, the error code in the response body
+	// and the transport error, any of them may be zero.
+	RetryOn func(status, code int, err error) bool
+}
+
+type retryPolicyKey struct{}
+
+func NewRetryPolicy(maxAttempts int) *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts: maxAttempts,
+		MinBackoff:  100 * time.Millisecond,
+		MaxBackoff:  5 * time.Second,
+		RetryOn:     DefaultRetryOn,
+	}
+}
+
+// DefaultRetryOn retries transport errors, 5xx responses and rate limits.
+func DefaultRetryOn(status, code int, err error) bool {
+	if err != nil {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	if status >= http.StatusInternalServerError || status == http.StatusTooManyRequests {
+		return true
+	}
+	return code == http.StatusTooManyRequests || code == http.StatusInternalServerError
+}
+
+// WithRetryPolicy overrides the client retry policy for calls made with ctx,
+// a nil policy disables retries.
+func WithRetryPolicy(ctx context.Context, p *RetryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, p)
+}
+
+func (c *Client) SetRetryPolicy(p *RetryPolicy) {
+	c.retry = p
+}
+
+func (c *Client) retryPolicy(ctx context.Context) *RetryPolicy {
+	if p, ok := ctx.Value(retryPolicyKey{}).(*RetryPolicy); ok {
+		return p
+	}
+	return c.retry
+}
+
+func (p *RetryPolicy) shouldRetry(attempt int, resp *apiResponse, err error) bool {
+	if p == nil || attempt >= p.MaxAttempts {
+		return false
+	}
+	retryOn := p.RetryOn
+	if retryOn == nil {
+		retryOn = DefaultRetryOn
+	}
+	var status int
+	if resp != nil {
+		status = resp.status
+	}
+	return retryOn(status, resp.errorCode(), err)
+}
+
+// backoff returns an exponential delay with jitter for the given attempt,
+// the result is always between half and the full exponential delay.
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.MinBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d = d * 2
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
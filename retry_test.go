@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicy(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	var ids []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-Id"))
+		switch len(ids) {
+		case 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case 2:
+			w.Write([]byte(`{"error":{"status":202,"code":429,"description":"Too many requests"}}`))
+		default:
+			w.Write([]byte(`{"data":{"user_id":"u"}}`))
+		}
+	}))
+	defer s.Close()
+
+	c := NewClient(nil)
+	c.SetBaseUri(s.URL)
+	p := NewRetryPolicy(3)
+	p.MinBackoff = time.Millisecond
+	c.SetRetryPolicy(p)
+
+	me, err := c.UserMe(ctx, "")
+	assert.Nil(err)
+	assert.Equal("u", me.UserId)
+	assert.Len(ids, 3)
+	assert.Equal(ids[0], ids[1])
+	assert.Equal(ids[0], ids[2])
+
+	ids = nil
+	_, err = c.Request(WithRetryPolicy(ctx, nil), "GET", "/", nil, "")
+	assert.NotNil(err)
+	assert.Len(ids, 1)
+
+	p = NewRetryPolicy(10)
+	p.MinBackoff = time.Millisecond
+	p.MaxBackoff = 4 * time.Millisecond
+	for i := 1; i < 10; i++ {
+		assert.LessOrEqual(p.backoff(i), p.MaxBackoff)
+		assert.GreaterOrEqual(p.backoff(i), p.MinBackoff/2)
+	}
+}
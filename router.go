@@ -0,0 +1,287 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+type MessageHandler func(ctx context.Context, msg MessageView, userId string) error
+
+type CommandHandler func(ctx context.Context, msg MessageView, userId string, cmd *Command) error
+
+type Middleware func(next MessageHandler) MessageHandler
+
+// Command is a text message matched by a command prefix, Args are the words
+// after the prefix, double or single quotes group words into one argument.
+type Command struct {
+	Name string
+	Args []string
+	Text string
+}
+
+type routerCommand struct {
+	prefix  string
+	handler CommandHandler
+}
+
+// Router is a BlazeListener dispatching messages to handlers. A text message
+// goes to the command with the longest matching prefix, other messages go to
+// the handler of their category, and the rest to the fallback. Messages
+// without any handler are ignored.
+type Router struct {
+	mutex       sync.RWMutex
+	categories  map[string]MessageHandler
+	commands    []*routerCommand
+	fallback    MessageHandler
+	receipt     MessageHandler
+	middlewares []Middleware
+	syncAck     bool
+}
+
+func NewRouter() *Router {
+	return &Router{
+		categories: make(map[string]MessageHandler),
+		syncAck:    true,
+	}
+}
+
+func (r *Router) Handle(category string, h MessageHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.categories[category] = h
+}
+
+// Command registers h for text messages starting with prefix, e.g. "/pay",
+// followed by a space or the end of the text. Prefixes match case
+// insensitively.
+func (r *Router) Command(prefix string, h CommandHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.commands = slices.DeleteFunc(r.commands, func(c *routerCommand) bool {
+		return strings.EqualFold(c.prefix, prefix)
+	})
+	r.commands = append(r.commands, &routerCommand{prefix: prefix, handler: h})
+	slices.SortStableFunc(r.commands, func(a, b *routerCommand) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+}
+
+func (r *Router) Fallback(h MessageHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.fallback = h
+}
+
+// Receipt registers h for the ACKNOWLEDGE_MESSAGE_RECEIPT messages, which
+// are not passed through the middlewares.
+func (r *Router) Receipt(h MessageHandler) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.receipt = h
+}
+
+// Use appends middlewares, the first one is the outermost.
+func (r *Router) Use(middlewares ...Middleware) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.middlewares = append(r.middlewares, middlewares...)
+}
+
+func (r *Router) SetSyncAck(syncAck bool) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.syncAck = syncAck
+}
+
+func (r *Router) OnMessage(ctx context.Context, msg MessageView, userId string) error {
+	r.mutex.RLock()
+	h := MessageHandler(r.dispatch)
+	for i := len(r.middlewares) - 1; i >= 0; i-- {
+		h = r.middlewares[i](h)
+	}
+	r.mutex.RUnlock()
+	return h(ctx, msg, userId)
+}
+
+func (r *Router) OnAckReceipt(ctx context.Context, msg MessageView, userId string) error {
+	r.mutex.RLock()
+	h := r.receipt
+	r.mutex.RUnlock()
+	if h == nil {
+		return nil
+	}
+	return h(ctx, msg, userId)
+}
+
+func (r *Router) SyncAck() bool {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.syncAck
+}
+
+func (r *Router) dispatch(ctx context.Context, msg MessageView, userId string) error {
+	r.mutex.RLock()
+	commands := r.commands
+	h, fallback := r.categories[msg.Category], r.fallback
+	r.mutex.RUnlock()
+
+	// a text which can't be decoded has no command, and goes to the handler
+	// of its category, failing would make the server redeliver it forever
+	if msg.Category == MessageCategoryPlainText && len(commands) > 0 {
+		data, _ := messageData(msg)
+		for _, c := range commands {
+			cmd := matchCommand(c.prefix, string(data))
+			if cmd != nil {
+				return c.handler(ctx, msg, userId, cmd)
+			}
+		}
+	}
+	if h == nil {
+		h = fallback
+	}
+	if h == nil {
+		return nil
+	}
+	return h(ctx, msg, userId)
+}
+
+func matchCommand(prefix, text string) *Command {
+	text = strings.TrimSpace(text)
+	if len(text) < len(prefix) || !strings.EqualFold(text[:len(prefix)], prefix) {
+		return nil
+	}
+	rest := text[len(prefix):]
+	if rest != "" && !unicode.IsSpace([]rune(rest)[0]) {
+		return nil
+	}
+	return &Command{Name: prefix, Args: ParseCommandArgs(rest), Text: text}
+}
+
+// ParseCommandArgs splits s into words by white spaces, a double or single
+// quoted part is kept in one word, and a backslash escapes the next rune.
+func ParseCommandArgs(s string) []string {
+	var args []string
+	var word strings.Builder
+	var quote rune
+	inWord, escaped := false, false
+	for _, c := range s {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			inWord, escaped = true, true
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			word.WriteRune(c)
+		case c == '"' || c == '\'':
+			inWord, quote = true, c
+		case unicode.IsSpace(c):
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			inWord = true
+			word.WriteRune(c)
+		}
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args
+}
+
+// LoggingMiddleware logs every message with its handling duration and error.
+func LoggingMiddleware(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	logger = slog.New(NewRedactHandler(logger.Handler()))
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg MessageView, userId string) error {
+			start := time.Now()
+			err := next(ctx, msg, userId)
+			level := slog.LevelDebug
+			if err != nil {
+				level = slog.LevelWarn
+			}
+			logger.Log(ctx, level, "blaze handle message",
+				"conversation_id", msg.ConversationId,
+				"message_id", msg.MessageId,
+				"user_id", msg.UserId,
+				"category", msg.Category,
+				"duration", time.Since(start),
+				"error", err)
+			return err
+		}
+	}
+}
+
+// RecoverMiddleware turns a panic of the handler into an error, so the
+// message is not acknowledged and will be delivered again.
+func RecoverMiddleware() Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg MessageView, userId string) (err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					err = fmt.Errorf("panic to handle %s %s: %v\n%s", msg.Category, msg.MessageId, p, debug.Stack())
+				}
+			}()
+			return next(ctx, msg, userId)
+		}
+	}
+}
+
+// AuthMiddleware drops the messages not allowed by allow, without an error
+// so they are still acknowledged.
+func AuthMiddleware(allow func(ctx context.Context, msg MessageView) bool) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg MessageView, userId string) error {
+			if !allow(ctx, msg) {
+				return nil
+			}
+			return next(ctx, msg, userId)
+		}
+	}
+}
+
+// AllowUsers allows the messages sent by one of ids.
+func AllowUsers(ids ...string) func(ctx context.Context, msg MessageView) bool {
+	return func(ctx context.Context, msg MessageView) bool {
+		return slices.Contains(ids, msg.UserId)
+	}
+}
+
+// DedupMiddleware skips the messages handled successfully before, it keeps
+// the ids of the last size messages.
+func DedupMiddleware(size int) Middleware {
+	return DedupStoreMiddleware(NewMemoryDedupStore(size, 0))
+}
+
+// DedupStoreMiddleware skips the messages seen by store, and records a
+// message only after the next handler returns nil.
+func DedupStoreMiddleware(store DedupStore) Middleware {
+	return func(next MessageHandler) MessageHandler {
+		return func(ctx context.Context, msg MessageView, userId string) error {
+			seen, err := store.Seen(ctx, msg.MessageId)
+			if err != nil || seen {
+				return err
+			}
+			err = next(ctx, msg, userId)
+			if err != nil {
+				return err
+			}
+			return store.Record(ctx, msg.MessageId)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package bot
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	text := func(id, userId, s string) MessageView {
+		return MessageView{
+			MessageId:  id,
+			UserId:     userId,
+			Category:   MessageCategoryPlainText,
+			DataBase64: base64.StdEncoding.EncodeToString([]byte(s)),
+		}
+	}
+
+	var calls []string
+	r := NewRouter()
+	r.Command("/pay", func(ctx context.Context, msg MessageView, userId string, cmd *Command) error {
+		calls = append(calls, fmt.Sprintf("pay %q", cmd.Args))
+		return nil
+	})
+	r.Command("/pay all", func(ctx context.Context, msg MessageView, userId string, cmd *Command) error {
+		calls = append(calls, fmt.Sprintf("all %q", cmd.Args))
+		return nil
+	})
+	r.Handle(MessageCategoryPlainText, func(ctx context.Context, msg MessageView, userId string) error {
+		calls = append(calls, "text")
+		return nil
+	})
+	r.Fallback(func(ctx context.Context, msg MessageView, userId string) error {
+		calls = append(calls, "fallback "+msg.Category)
+		return nil
+	})
+	r.Use(AuthMiddleware(AllowUsers("alice")), RecoverMiddleware(), DedupMiddleware(2))
+
+	assert.Nil(r.OnMessage(ctx, text("1", "alice", ` /PAY 1.5 "for lunch" it\'s`), ""))
+	assert.Nil(r.OnMessage(ctx, text("2", "alice", "/pay all"), ""))
+	assert.Nil(r.OnMessage(ctx, text("3", "alice", "/payment"), ""))
+	assert.Nil(r.OnMessage(ctx, MessageView{MessageId: "4", UserId: "alice", Category: MessageCategoryAppCard}, ""))
+	assert.Nil(r.OnMessage(ctx, text("5", "bob", "/pay 1"), ""))
+	assert.Nil(r.OnMessage(ctx, text("3", "alice", "/payment"), ""))
+	malformed := MessageView{MessageId: "7", UserId: "alice", Category: MessageCategoryPlainText, DataBase64: "%%%"}
+	assert.Nil(r.OnMessage(ctx, malformed, ""))
+	assert.Equal([]string{
+		`pay ["1.5" "for lunch" "it's"]`,
+		`all []`,
+		"text",
+		"fallback APP_CARD",
+		"text",
+	}, calls)
+
+	r.Handle(MessageCategoryAppCard, func(ctx context.Context, msg MessageView, userId string) error {
+		panic("card")
+	})
+	err := r.OnMessage(ctx, MessageView{MessageId: "6", UserId: "alice", Category: MessageCategoryAppCard}, "")
+	assert.ErrorContains(err, "panic to handle APP_CARD 6: card")
+	assert.True(r.SyncAck())
+}
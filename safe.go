@@ -53,17 +53,21 @@ func NewSafeUser(userId, sessionId, sessionPrivateKey string) *SafeUser {
 	}
 }
 
-func RequestSafeGhostKeys(ctx context.Context, gkr []*GhostKeyRequest, user *SafeUser) ([]*GhostKeys, error) {
+func RequestSafeGhostKeys(ctx context.Context, gkr []*GhostKeyRequest, auth Authorizer) ([]*GhostKeys, error) {
+	return defaultClient.WithAuthorizer(auth).RequestSafeGhostKeys(ctx, gkr)
+}
+
+func (c *Client) RequestSafeGhostKeys(ctx context.Context, gkr []*GhostKeyRequest) ([]*GhostKeys, error) {
 	data, err := json.Marshal(gkr)
 	if err != nil {
 		return nil, err
 	}
 	method, path := "POST", "/safe/keys"
-	token, err := SignAuthenticationToken(method, path, string(data), user)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
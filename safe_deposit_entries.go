@@ -17,7 +17,11 @@ type DepositEntryView struct {
 	IsPrimary     bool     `json:"is_primary"`
 }
 
-func CreateDepositEntry(ctx context.Context, chainID string, members []string, threshold int64, user *SafeUser) ([]*DepositEntryView, error) {
+func CreateDepositEntry(ctx context.Context, chainID string, members []string, threshold int64, auth Authorizer) ([]*DepositEntryView, error) {
+	return defaultClient.WithAuthorizer(auth).CreateDepositEntry(ctx, chainID, members, threshold)
+}
+
+func (c *Client) CreateDepositEntry(ctx context.Context, chainID string, members []string, threshold int64) ([]*DepositEntryView, error) {
 	data, _ := json.Marshal(map[string]any{
 		"chain_id":  chainID,
 		"members":   members,
@@ -25,11 +29,11 @@ func CreateDepositEntry(ctx context.Context, chainID string, members []string, t
 	})
 	endpoint := "/safe/deposit/entries"
 
-	token, err := SignAuthenticationToken("POST", endpoint, string(data), user)
+	token, err := c.sign("POST", endpoint, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", endpoint, data, token)
+	body, err := c.Request(ctx, "POST", endpoint, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -29,8 +29,12 @@ type SafeDepositPending struct {
 }
 
 func FetchPendingSafeDeposits(ctx context.Context) ([]*SafeDepositPending, error) {
+	return defaultClient.FetchPendingSafeDeposits(ctx)
+}
+
+func (c *Client) FetchPendingSafeDeposits(ctx context.Context) ([]*SafeDepositPending, error) {
 	endpoint := "/safe/deposits"
-	body, err := Request(ctx, "GET", endpoint, nil, "")
+	body, err := c.Request(ctx, "GET", endpoint, nil, "")
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
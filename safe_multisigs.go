@@ -43,17 +43,21 @@ type SafeMultisigRequest struct {
 	Views           []string              `json:"views,omitempty"`
 }
 
-func CreateSafeMultisigRequest(ctx context.Context, request []*KernelTransactionRequestCreateRequest, user *SafeUser) ([]*SafeMultisigRequest, error) {
+func CreateSafeMultisigRequest(ctx context.Context, request []*KernelTransactionRequestCreateRequest, auth Authorizer) ([]*SafeMultisigRequest, error) {
+	return defaultClient.WithAuthorizer(auth).CreateSafeMultisigRequest(ctx, request)
+}
+
+func (c *Client) CreateSafeMultisigRequest(ctx context.Context, request []*KernelTransactionRequestCreateRequest) ([]*SafeMultisigRequest, error) {
 	data, err := json.Marshal(request)
 	if err != nil {
 		return nil, err
 	}
 	endpoint := "/safe/multisigs"
-	token, err := SignAuthenticationToken("POST", endpoint, string(data), user)
+	token, err := c.sign("POST", endpoint, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", endpoint, data, token)
+	body, err := c.Request(ctx, "POST", endpoint, data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -71,13 +75,17 @@ func CreateSafeMultisigRequest(ctx context.Context, request []*KernelTransaction
 	return resp.Data, nil
 }
 
-func FetchSafeMultisigRequest(ctx context.Context, idOrHash string, user *SafeUser) (*SafeMultisigRequest, error) {
+func FetchSafeMultisigRequest(ctx context.Context, idOrHash string, auth Authorizer) (*SafeMultisigRequest, error) {
+	return defaultClient.WithAuthorizer(auth).FetchSafeMultisigRequest(ctx, idOrHash)
+}
+
+func (c *Client) FetchSafeMultisigRequest(ctx context.Context, idOrHash string) (*SafeMultisigRequest, error) {
 	endpoint := "/safe/multisigs/" + idOrHash
-	token, err := SignAuthenticationToken("GET", endpoint, "", user)
+	token, err := c.sign("GET", endpoint, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", endpoint, nil, token)
+	body, err := c.Request(ctx, "GET", endpoint, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -95,7 +103,11 @@ func FetchSafeMultisigRequest(ctx context.Context, idOrHash string, user *SafeUs
 	return &resp.Data, nil
 }
 
-func CreateMultisigRawTx(ctx context.Context, asset crypto.Hash, senders, receivers []string, threshold byte, inputs []*common.UTXO, amount common.Integer, traceId, extra string, su *SafeUser) (string, error) {
+func CreateMultisigRawTx(ctx context.Context, asset crypto.Hash, senders, receivers []string, threshold byte, inputs []*common.UTXO, amount common.Integer, traceId, extra string, auth Authorizer) (string, error) {
+	return defaultClient.WithAuthorizer(auth).CreateMultisigRawTx(ctx, asset, senders, receivers, threshold, inputs, amount, traceId, extra)
+}
+
+func (c *Client) CreateMultisigRawTx(ctx context.Context, asset crypto.Hash, senders, receivers []string, threshold byte, inputs []*common.UTXO, amount common.Integer, traceId, extra string) (string, error) {
 	out := &GhostKeyRequest{
 		Receivers: receivers,
 		Index:     0,
@@ -106,7 +118,7 @@ func CreateMultisigRawTx(ctx context.Context, asset crypto.Hash, senders, receiv
 		Index:     1,
 		Hint:      UniqueObjectId(traceId, "OUTPUT", "1"),
 	}
-	ghostKeys, err := RequestSafeGhostKeys(ctx, []*GhostKeyRequest{out, change}, su)
+	ghostKeys, err := c.RequestSafeGhostKeys(ctx, []*GhostKeyRequest{out, change})
 	if err != nil {
 		return "", err
 	}
@@ -14,7 +14,11 @@ import (
 )
 
 func RegisterSafeWithSetupPin(ctx context.Context, su *SafeUser) (*User, error) {
-	seed, err := hex.DecodeString(su.SpendPrivateKey)
+	return defaultClient.WithSafeUser(su).RegisterSafeWithSetupPin(ctx)
+}
+
+func (c *Client) RegisterSafeWithSetupPin(ctx context.Context) (*User, error) {
+	seed, err := hex.DecodeString(c.user.SpendPrivateKey)
 	if err != nil {
 		return nil, err
 	}
@@ -24,31 +28,35 @@ func RegisterSafeWithSetupPin(ctx context.Context, su *SafeUser) (*User, error)
 	counter := make([]byte, 8)
 	binary.BigEndian.PutUint64(counter, 1)
 	pubTipBuf := append(spendPublicKey, counter...)
-	encryptedPin, err := EncryptEd25519PIN(hex.EncodeToString(pubTipBuf), uint64(time.Now().UnixNano()), su)
+	encryptedPin, err := EncryptEd25519PIN(hex.EncodeToString(pubTipBuf), uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
-	err = UpdatePin(ctx, "", encryptedPin, su)
+	err = c.UpdatePin(ctx, "", encryptedPin)
 	if err != nil {
 		return nil, fmt.Errorf("update pin error: %w", err)
 	}
-	return RegisterSafeBareUser(ctx, su)
+	return c.RegisterSafeBareUser(ctx)
 }
 
 func RegisterSafeBareUser(ctx context.Context, su *SafeUser) (*User, error) {
-	s, err := hex.DecodeString(su.SpendPrivateKey)
+	return defaultClient.WithSafeUser(su).RegisterSafeBareUser(ctx)
+}
+
+func (c *Client) RegisterSafeBareUser(ctx context.Context) (*User, error) {
+	s, err := hex.DecodeString(c.user.SpendPrivateKey)
 	if err != nil {
 		return nil, err
 	}
 	private := ed25519.NewKeyFromSeed(s)
-	h := crypto.Sha256Hash([]byte(su.UserId))
+	h := crypto.Sha256Hash([]byte(c.user.UserId))
 	signBytes := ed25519.Sign(private, h[:])
 	signature := base64.RawURLEncoding.EncodeToString(signBytes[:])
 	publicKey := hex.EncodeToString(private[32:])
-	tipBody := TIPBodyForSequencerRegister(su.UserId, publicKey)
+	tipBody := TIPBodyForSequencerRegister(c.user.UserId, publicKey)
 	sigBuf := ed25519.Sign(private, tipBody)
 
-	encryptedPIN, err := EncryptEd25519PIN(hex.EncodeToString(sigBuf), uint64(time.Now().UnixNano()), su)
+	encryptedPIN, err := EncryptEd25519PIN(hex.EncodeToString(sigBuf), uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -58,11 +66,11 @@ func RegisterSafeBareUser(ctx context.Context, su *SafeUser) (*User, error) {
 		"pin_base64": encryptedPIN,
 	})
 
-	token, err := SignAuthenticationToken("POST", "/safe/users", string(data), su)
+	token, err := c.sign("POST", "/safe/users", string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/safe/users", data, token)
+	body, err := c.Request(ctx, "POST", "/safe/users", data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -83,6 +91,12 @@ func RegisterSafeBareUser(ctx context.Context, su *SafeUser) (*User, error) {
 // If you want to register safe user, you need to call UpdateTipPin upgrade TIP PIN first.
 // Deprecated, use RegisterSafeBareUser instead.
 func RegisterSafe(ctx context.Context, spendPrivateKeyOrSeed string, su *SafeUser) (*UserMeView, error) {
+	return defaultClient.WithSafeUser(su).RegisterSafe(ctx, spendPrivateKeyOrSeed)
+}
+
+// If you want to register safe user, you need to call UpdateTipPin upgrade TIP PIN first.
+// Deprecated, use RegisterSafeBareUser instead.
+func (c *Client) RegisterSafe(ctx context.Context, spendPrivateKeyOrSeed string) (*UserMeView, error) {
 	spend, err := hex.DecodeString(spendPrivateKeyOrSeed)
 	if err != nil {
 		return nil, err
@@ -96,21 +110,21 @@ func RegisterSafe(ctx context.Context, spendPrivateKeyOrSeed string, su *SafeUse
 	default:
 		return nil, fmt.Errorf("invalid seed length")
 	}
-	h := crypto.Sha256Hash([]byte(su.UserId))
+	h := crypto.Sha256Hash([]byte(c.user.UserId))
 	signBytes := ed25519.Sign(private, h[:])
 	signature := base64.RawURLEncoding.EncodeToString(signBytes[:])
 	publicKey := hex.EncodeToString(private[32:])
-	tipBody := TIPBodyForSequencerRegister(su.UserId, publicKey)
-	pinBuf, err := hex.DecodeString(su.SpendPrivateKey)
+	tipBody := TIPBodyForSequencerRegister(c.user.UserId, publicKey)
+	pinBuf, err := hex.DecodeString(c.user.SpendPrivateKey)
 	if err != nil {
 		return nil, err
 	}
-	if su.SpendPrivateKey != hex.EncodeToString(private) {
+	if c.user.SpendPrivateKey != hex.EncodeToString(private) {
 		panic("please use the same spend private key with tip private key, spend private key must not be empty")
 	}
 	sigBuf := ed25519.Sign(ed25519.PrivateKey(pinBuf), tipBody)
 
-	encryptedPIN, err := EncryptEd25519PIN(hex.EncodeToString(sigBuf), uint64(time.Now().UnixNano()), su)
+	encryptedPIN, err := EncryptEd25519PIN(hex.EncodeToString(sigBuf), uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return nil, err
 	}
@@ -120,11 +134,11 @@ func RegisterSafe(ctx context.Context, spendPrivateKeyOrSeed string, su *SafeUse
 		"pin_base64": encryptedPIN,
 	})
 
-	token, err := SignAuthenticationToken("POST", "/safe/users", string(data), su)
+	token, err := c.sign("POST", "/safe/users", string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/safe/users", data, token)
+	body, err := c.Request(ctx, "POST", "/safe/users", data, token)
 	if err != nil {
 		return nil, err
 	}
@@ -12,17 +12,21 @@ type UserSession struct {
 	Platform  string `json:"platform"`
 }
 
-func FetchUserSession(ctx context.Context, users []string, su *SafeUser) ([]*UserSession, error) {
+func FetchUserSession(ctx context.Context, users []string, auth Authorizer) ([]*UserSession, error) {
+	return defaultClient.WithAuthorizer(auth).FetchUserSession(ctx, users)
+}
+
+func (c *Client) FetchUserSession(ctx context.Context, users []string) ([]*UserSession, error) {
 	data, err := json.Marshal(users)
 	if err != nil {
 		return nil, err
 	}
 	method, path := "POST", "/sessions/fetch"
-	token, err := SignAuthenticationToken(method, path, string(data), su)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -39,7 +39,11 @@ type SafeSnapshot struct {
 	Withdrawal *SafeWithdrawalView `json:"withdrawal,omitempty"`
 }
 
-func SafeSnapshots(ctx context.Context, limit int, app, assetId, opponent, offset string, su *SafeUser) ([]*SafeSnapshot, error) {
+func SafeSnapshots(ctx context.Context, limit int, app, assetId, opponent, offset string, auth Authorizer) ([]*SafeSnapshot, error) {
+	return defaultClient.WithAuthorizer(auth).SafeSnapshots(ctx, limit, app, assetId, opponent, offset)
+}
+
+func (c *Client) SafeSnapshots(ctx context.Context, limit int, app, assetId, opponent, offset string) ([]*SafeSnapshot, error) {
 	v := url.Values{}
 	v.Set("limit", strconv.Itoa(limit))
 	if app != "" {
@@ -55,30 +59,11 @@ func SafeSnapshots(ctx context.Context, limit int, app, assetId, opponent, offse
 		v.Set("opponent", opponent)
 	}
 	path := "/safe/snapshots?" + v.Encode()
-	token, err := SignAuthenticationToken("GET", path, "", su)
+	token, err := c.sign("GET", path, "")
 	if err != nil {
 		return nil, err
 	}
-	return SafeSnapshotsByToken(ctx, limit, app, assetId, opponent, offset, token)
-}
-
-func SafeSnapshotsByToken(ctx context.Context, limit int, app, assetId, opponent, offset, accessToken string) ([]*SafeSnapshot, error) {
-	v := url.Values{}
-	v.Set("limit", strconv.Itoa(limit))
-	if app != "" {
-		v.Set("app", app)
-	}
-	if assetId != "" {
-		v.Set("asset", assetId)
-	}
-	if offset != "" {
-		v.Set("offset", offset)
-	}
-	if opponent != "" {
-		v.Set("opponent", opponent)
-	}
-	path := "/safe/snapshots?" + v.Encode()
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+	body, err := c.Request(ctx, "GET", path, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -97,18 +82,22 @@ func SafeSnapshotsByToken(ctx context.Context, limit int, app, assetId, opponent
 	return resp.Data, nil
 }
 
-func SafeSnapshotById(ctx context.Context, snapshotId string, su *SafeUser) (*SafeSnapshot, error) {
+// Deprecated: use SafeSnapshots with BearerToken(accessToken).
+func SafeSnapshotsByToken(ctx context.Context, limit int, app, assetId, opponent, offset, accessToken string) ([]*SafeSnapshot, error) {
+	return SafeSnapshots(ctx, limit, app, assetId, opponent, offset, BearerToken(accessToken))
+}
+
+func SafeSnapshotById(ctx context.Context, snapshotId string, auth Authorizer) (*SafeSnapshot, error) {
+	return defaultClient.WithAuthorizer(auth).SafeSnapshotById(ctx, snapshotId)
+}
+
+func (c *Client) SafeSnapshotById(ctx context.Context, snapshotId string) (*SafeSnapshot, error) {
 	path := "/safe/snapshots/" + snapshotId
-	token, err := SignAuthenticationToken("GET", path, "", su)
+	token, err := c.sign("GET", path, "")
 	if err != nil {
 		return nil, err
 	}
-	return SafeSnapshotByToken(ctx, snapshotId, token)
-}
-
-func SafeSnapshotByToken(ctx context.Context, snapshotId string, accessToken string) (*SafeSnapshot, error) {
-	path := "/safe/snapshots/" + snapshotId
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+	body, err := c.Request(ctx, "GET", path, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -127,6 +116,11 @@ func SafeSnapshotByToken(ctx context.Context, snapshotId string, accessToken str
 	return resp.Data, nil
 }
 
+// Deprecated: use SafeSnapshotById with BearerToken(accessToken).
+func SafeSnapshotByToken(ctx context.Context, snapshotId string, accessToken string) (*SafeSnapshot, error) {
+	return SafeSnapshotById(ctx, snapshotId, BearerToken(accessToken))
+}
+
 type MessageWithSession struct {
 	Type             string    `json:"type"`
 	RepresentativeId string    `json:"representative_id"`
@@ -146,7 +140,11 @@ type MessageWithSession struct {
 	UpdatedAt        time.Time `json:"updated_at"`
 }
 
-func SafeNotifySnapshot(ctx context.Context, transactionHash string, outputIndex int64, receiverID string, su *SafeUser) (*MessageWithSession, error) {
+func SafeNotifySnapshot(ctx context.Context, transactionHash string, outputIndex int64, receiverID string, auth Authorizer) (*MessageWithSession, error) {
+	return defaultClient.WithAuthorizer(auth).SafeNotifySnapshot(ctx, transactionHash, outputIndex, receiverID)
+}
+
+func (c *Client) SafeNotifySnapshot(ctx context.Context, transactionHash string, outputIndex int64, receiverID string) (*MessageWithSession, error) {
 	data, err := json.Marshal(map[string]any{
 		"transaction_hash": transactionHash,
 		"output_index":     outputIndex,
@@ -156,11 +154,11 @@ func SafeNotifySnapshot(ctx context.Context, transactionHash string, outputIndex
 		return nil, err
 	}
 	method, path := "POST", "/safe/snapshots/notifications"
-	token, err := SignAuthenticationToken(method, path, string(data), su)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, err
 	}
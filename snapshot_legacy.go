@@ -64,32 +64,15 @@ type LegacySnapshotShort struct {
 }
 
 func Snapshots(ctx context.Context, limit int, offset, assetId, order, uid, sid, sessionKey string) ([]*LegacySnapshot, error) {
-	v := url.Values{}
-	v.Set("limit", strconv.Itoa(limit))
-	if offset != "" {
-		v.Set("offset", offset)
-	}
-	if assetId != "" {
-		v.Set("asset", assetId)
-	}
-	if order != "" {
-		v.Set("order", order)
-	}
-
-	path := "/snapshots?" + v.Encode()
 	su := &SafeUser{
 		UserId:            uid,
 		SessionId:         sid,
 		SessionPrivateKey: sessionKey,
 	}
-	token, err := SignAuthenticationToken("GET", path, "", su)
-	if err != nil {
-		return nil, err
-	}
-	return SnapshotsByToken(ctx, limit, offset, assetId, order, token)
+	return defaultClient.WithSafeUser(su).Snapshots(ctx, limit, offset, assetId, order)
 }
 
-func SnapshotsByToken(ctx context.Context, limit int, offset, assetId, order, accessToken string) ([]*LegacySnapshot, error) {
+func (c *Client) Snapshots(ctx context.Context, limit int, offset, assetId, order string) ([]*LegacySnapshot, error) {
 	v := url.Values{}
 	v.Set("limit", strconv.Itoa(limit))
 	if offset != "" {
@@ -103,7 +86,11 @@ func SnapshotsByToken(ctx context.Context, limit int, offset, assetId, order, ac
 	}
 
 	path := "/snapshots?" + v.Encode()
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+	token, err := c.sign("GET", path, "")
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.Request(ctx, "GET", path, nil, token)
 	if err != nil {
 		return nil, err
 	}
@@ -122,36 +109,31 @@ func SnapshotsByToken(ctx context.Context, limit int, offset, assetId, order, ac
 	return resp.Data, nil
 }
 
+// Deprecated: use Client.Snapshots on a client made by WithAuthorizer(BearerToken(accessToken)).
+func SnapshotsByToken(ctx context.Context, limit int, offset, assetId, order, accessToken string) ([]*LegacySnapshot, error) {
+	return defaultClient.WithAuthorizer(BearerToken(accessToken)).Snapshots(ctx, limit, offset, assetId, order)
+}
+
 func SnapshotById(ctx context.Context, snapshotId string, uid, sid, sessionKey string) (*LegacySnapshot, error) {
 	su := &SafeUser{
 		UserId:            uid,
 		SessionId:         sid,
 		SessionPrivateKey: sessionKey,
 	}
-	path := "/snapshots/" + snapshotId
-	token, err := SignAuthenticationToken("GET", path, "", su)
-	if err != nil {
-		return nil, err
-	}
-	return SnapshotByToken(ctx, snapshotId, token)
+	return defaultClient.WithSafeUser(su).SnapshotById(ctx, snapshotId)
 }
 
-func SnapshotByTraceId(ctx context.Context, traceId string, uid, sid, sessionKey string) (*LegacySnapshot, error) {
-	su := &SafeUser{
-		UserId:            uid,
-		SessionId:         sid,
-		SessionPrivateKey: sessionKey,
-	}
-	path := "/snapshots/trace/" + traceId
-	token, err := SignAuthenticationToken("GET", path, "", su)
+func (c *Client) SnapshotById(ctx context.Context, snapshotId string) (*LegacySnapshot, error) {
+	path := "/snapshots/" + snapshotId
+	token, err := c.sign("GET", path, "")
 	if err != nil {
 		return nil, err
 	}
-
-	body, err := Request(ctx, "GET", path, nil, token)
+	body, err := c.Request(ctx, "GET", path, nil, token)
 	if err != nil {
 		return nil, err
 	}
+
 	var resp struct {
 		Data  *LegacySnapshot `json:"data"`
 		Error Error           `json:"error"`
@@ -166,13 +148,26 @@ func SnapshotByTraceId(ctx context.Context, traceId string, uid, sid, sessionKey
 	return resp.Data, nil
 }
 
-func SnapshotByToken(ctx context.Context, snapshotId string, accessToken string) (*LegacySnapshot, error) {
-	path := "/snapshots/" + snapshotId
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+func SnapshotByTraceId(ctx context.Context, traceId string, uid, sid, sessionKey string) (*LegacySnapshot, error) {
+	su := &SafeUser{
+		UserId:            uid,
+		SessionId:         sid,
+		SessionPrivateKey: sessionKey,
+	}
+	return defaultClient.WithSafeUser(su).SnapshotByTraceId(ctx, traceId)
+}
+
+func (c *Client) SnapshotByTraceId(ctx context.Context, traceId string) (*LegacySnapshot, error) {
+	path := "/snapshots/trace/" + traceId
+	token, err := c.sign("GET", path, "")
 	if err != nil {
 		return nil, err
 	}
 
+	body, err := c.Request(ctx, "GET", path, nil, token)
+	if err != nil {
+		return nil, err
+	}
 	var resp struct {
 		Data  *LegacySnapshot `json:"data"`
 		Error Error           `json:"error"`
@@ -187,13 +182,27 @@ func SnapshotByToken(ctx context.Context, snapshotId string, accessToken string)
 	return resp.Data, nil
 }
 
+// Deprecated: use Client.SnapshotById on a client made by WithAuthorizer(BearerToken(accessToken)).
+func SnapshotByToken(ctx context.Context, snapshotId string, accessToken string) (*LegacySnapshot, error) {
+	return defaultClient.WithAuthorizer(BearerToken(accessToken)).SnapshotById(ctx, snapshotId)
+}
+
 func NetworkSnapshot(ctx context.Context, snapshotId string) (*LegacySnapshot, error) {
-	return NetworkSnapshotByToken(ctx, snapshotId, "")
+	return defaultClient.WithoutAuthorizer().NetworkSnapshot(ctx, snapshotId)
 }
 
-func NetworkSnapshotByToken(ctx context.Context, snapshotId, accessToken string) (*LegacySnapshot, error) {
+// NetworkSnapshot signs the request only when the client has an authorizer.
+func (c *Client) NetworkSnapshot(ctx context.Context, snapshotId string) (*LegacySnapshot, error) {
 	path := "/network/snapshots/" + snapshotId
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+	accessToken := ""
+	if c.auth != nil {
+		var err error
+		accessToken, err = c.sign("GET", path, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	body, err := c.Request(ctx, "GET", path, nil, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -212,11 +221,29 @@ func NetworkSnapshotByToken(ctx context.Context, snapshotId, accessToken string)
 	return resp.Data, nil
 }
 
+// Deprecated: use Client.NetworkSnapshot on a client made by WithAuthorizer(BearerToken(accessToken)).
+func NetworkSnapshotByToken(ctx context.Context, snapshotId, accessToken string) (*LegacySnapshot, error) {
+	return defaultClient.WithAuthorizer(BearerToken(accessToken)).NetworkSnapshot(ctx, snapshotId)
+}
+
 func NetworkSnapshots(ctx context.Context, limit int, offset, assetId, order string) ([]*LegacySnapshotShort, error) {
-	return NetworkSnapshotsByToken(ctx, limit, offset, assetId, order, "", "", "")
+	return defaultClient.WithoutAuthorizer().NetworkSnapshots(ctx, limit, offset, assetId, order)
 }
 
 func NetworkSnapshotsByToken(ctx context.Context, limit int, offset, assetId, order, uid, sid, sessionKey string) ([]*LegacySnapshotShort, error) {
+	var su *SafeUser
+	if sessionKey != "" {
+		su = &SafeUser{
+			UserId:            uid,
+			SessionId:         sid,
+			SessionPrivateKey: sessionKey,
+		}
+	}
+	return defaultClient.WithSafeUser(su).NetworkSnapshots(ctx, limit, offset, assetId, order)
+}
+
+// NetworkSnapshots signs the request only when the client has an authorizer.
+func (c *Client) NetworkSnapshots(ctx context.Context, limit int, offset, assetId, order string) ([]*LegacySnapshotShort, error) {
 	v := url.Values{}
 	v.Set("limit", strconv.Itoa(limit))
 	if offset != "" {
@@ -231,19 +258,14 @@ func NetworkSnapshotsByToken(ctx context.Context, limit int, offset, assetId, or
 
 	path := "/network/snapshots?" + v.Encode()
 	accessToken := ""
-	if sessionKey != "" {
+	if c.auth != nil {
 		var err error
-		su := &SafeUser{
-			UserId:            uid,
-			SessionId:         sid,
-			SessionPrivateKey: sessionKey,
-		}
-		accessToken, err = SignAuthenticationToken("GET", path, "", su)
+		accessToken, err = c.sign("GET", path, "")
 		if err != nil {
 			return nil, err
 		}
 	}
-	body, err := Request(ctx, "GET", path, nil, accessToken)
+	body, err := c.Request(ctx, "GET", path, nil, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,107 @@
+package bot
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/MixinNetwork/bot-api-go-client/v3"
+
+// telemetry holds the tracer and instruments of a client, it uses the global
+// otel providers by default, which record nothing until the application
+// installs an SDK.
+type telemetry struct {
+	tracer         trace.Tracer
+	requestLatency metric.Float64Histogram
+	requestErrors  metric.Int64Counter
+	blazeLatency   metric.Float64Histogram
+	blazeErrors    metric.Int64Counter
+	blazeReconnect metric.Int64Counter
+}
+
+func newTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) *telemetry {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+	if mp == nil {
+		mp = otel.GetMeterProvider()
+	}
+	meter := mp.Meter(instrumentationName)
+	t := &telemetry{tracer: tp.Tracer(instrumentationName)}
+	t.requestLatency, _ = meter.Float64Histogram("mixin.client.request.duration",
+		metric.WithDescription("Duration of each HTTP call to the Mixin API."), metric.WithUnit("s"))
+	t.requestErrors, _ = meter.Int64Counter("mixin.client.request.errors",
+		metric.WithDescription("Failed HTTP calls by status and Mixin error code."))
+	t.blazeLatency, _ = meter.Float64Histogram("mixin.blaze.action.duration",
+		metric.WithDescription("Duration of Blaze actions until the server replied."), metric.WithUnit("s"))
+	t.blazeErrors, _ = meter.Int64Counter("mixin.blaze.action.errors",
+		metric.WithDescription("Failed Blaze actions by error code."))
+	t.blazeReconnect, _ = meter.Int64Counter("mixin.blaze.reconnects",
+		metric.WithDescription("Blaze connections made after the first one."))
+	return t
+}
+
+func (c *Client) SetTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) {
+	c.telemetry = newTelemetry(tp, mp)
+}
+
+func SetTelemetry(tp trace.TracerProvider, mp metric.MeterProvider) {
+	defaultClient.SetTelemetry(tp, mp)
+}
+
+func (t *telemetry) start(ctx context.Context, name string, kind trace.SpanKind, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return t.tracer.Start(ctx, name, trace.WithSpanKind(kind), trace.WithAttributes(attrs...))
+}
+
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (t *telemetry) recordRequest(ctx context.Context, method, path string, start time.Time, resp *apiResponse, err error) {
+	attrs := []attribute.KeyValue{
+		attribute.String("http.request.method", method),
+		attribute.String("mixin.endpoint", EndpointGroup(path)),
+	}
+	if resp != nil {
+		attrs = append(attrs, attribute.Int("http.response.status_code", resp.status))
+	}
+	t.requestLatency.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+
+	switch {
+	case err != nil:
+		attrs = append(attrs, attribute.String("error.type", "transport"))
+	case resp.status >= 400:
+		attrs = append(attrs, attribute.Int("mixin.error.code", resp.status))
+	default:
+		code := resp.errorCode()
+		if code == 0 {
+			return
+		}
+		attrs = append(attrs, attribute.Int("mixin.error.code", code))
+	}
+	t.requestErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
+
+func (t *telemetry) recordBlaze(ctx context.Context, action string, start time.Time, code int, err error) {
+	attrs := []attribute.KeyValue{attribute.String("mixin.blaze.action", action)}
+	t.blazeLatency.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(attrs...))
+	switch {
+	case code > 0:
+		attrs = append(attrs, attribute.Int("mixin.error.code", code))
+	case err != nil:
+		attrs = append(attrs, attribute.String("error.type", "timeout"))
+	default:
+		return
+	}
+	t.blazeErrors.Add(ctx, 1, metric.WithAttributes(attrs...))
+}
@@ -0,0 +1,58 @@
+package bot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTelemetryRequestId(t *testing.T) {
+	assert := assert.New(t)
+
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagator)
+
+	var requestIds, parents []string
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestIds = append(requestIds, r.Header.Get("X-Request-Id"))
+		parents = append(parents, r.Header.Get("Traceparent"))
+		w.Header().Set("X-Request-Id", r.Header.Get("X-Request-Id"))
+		w.Write([]byte(`{"data":{}}`))
+	}))
+	defer s.Close()
+
+	c := NewClient(nil)
+	c.SetBaseUri(s.URL)
+	c.SetTelemetry(nil, nil)
+
+	_, err := c.Request(context.Background(), "GET", "/me", nil, "")
+	assert.Nil(err)
+	assert.Len(requestIds, 1)
+	_, err = UuidFromString(requestIds[0])
+	assert.Nil(err)
+
+	// the calls of one trace have their own request ids, and share the trace
+	traceId, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanId, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceId,
+		SpanID:     spanId,
+		TraceFlags: trace.FlagsSampled,
+	}))
+	_, err = c.Request(ctx, "POST", "/safe/keys", nil, "")
+	assert.Nil(err)
+	_, err = c.Request(ctx, "POST", "/safe/transactions", nil, "")
+	assert.Nil(err)
+	assert.Len(requestIds, 3)
+	assert.NotEqual(requestIds[1], requestIds[2])
+	assert.NotEqual("4bf92f35-77b3-4da6-a3ce-929d0e0e4736", requestIds[1])
+	assert.Contains(parents[1], traceId.String())
+	assert.Contains(parents[2], traceId.String())
+}
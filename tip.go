@@ -40,8 +40,12 @@ type TipNodeData struct {
 }
 
 func GetTipNodeByPathWithRequestId(ctx context.Context, path, requestId string) (*TipNodeData, error) {
+	return defaultClient.GetTipNodeByPathWithRequestId(ctx, path, requestId)
+}
+
+func (c *Client) GetTipNodeByPathWithRequestId(ctx context.Context, path, requestId string) (*TipNodeData, error) {
 	url := fmt.Sprintf("/external/tip/%s", path)
-	body, err := RequestWithId(ctx, "GET", url, nil, "", requestId)
+	body, err := c.RequestWithId(ctx, "GET", url, nil, "", requestId)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -60,7 +64,11 @@ func GetTipNodeByPathWithRequestId(ctx context.Context, path, requestId string)
 }
 
 func GetTipNodeByPath(ctx context.Context, path string) (*TipNodeData, error) {
-	return GetTipNodeByPathWithRequestId(ctx, path, UuidNewV4().String())
+	return defaultClient.GetTipNodeByPath(ctx, path)
+}
+
+func (c *Client) GetTipNodeByPath(ctx context.Context, path string) (*TipNodeData, error) {
+	return c.GetTipNodeByPathWithRequestId(ctx, path, UuidNewV4().String())
 }
 
 func TIPMigrateBody(pub ed25519.PublicKey) string {
@@ -7,7 +7,6 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
 	"math/big"
 	"time"
 
@@ -15,6 +14,8 @@ import (
 	"github.com/MixinNetwork/mixin/common"
 	"github.com/MixinNetwork/mixin/crypto"
 	"github.com/gofrs/uuid/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type TransactionRecipient struct {
@@ -66,7 +67,15 @@ func (ue *UtxoInsufficientError) Error() string {
 	return fmt.Sprintf("insufficient outputs %s@%d %s", ue.TotalInput, ue.OutputSize, ue.TotalOutput)
 }
 
+func (ue *UtxoInsufficientError) Is(target error) bool {
+	return ErrInsufficientBalance.Is(target)
+}
+
 func SendTransferTransaction(ctx context.Context, assetId, receiver, amount, traceId string, extra []byte, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).SendTransferTransaction(ctx, assetId, receiver, amount, traceId, extra)
+}
+
+func (c *Client) SendTransferTransaction(ctx context.Context, assetId, receiver, amount, traceId string, extra []byte) (*SequencerTransactionRequest, error) {
 	if uuid.FromStringOrNil(receiver).String() != receiver {
 		return nil, fmt.Errorf("invalid receiver %s", receiver)
 	}
@@ -75,10 +84,14 @@ func SendTransferTransaction(ctx context.Context, assetId, receiver, amount, tra
 		MixAddress: ma,
 		Amount:     amount,
 	}
-	return SendTransaction(ctx, assetId, []*TransactionRecipient{tr}, traceId, extra, nil, u)
+	return c.SendTransaction(ctx, assetId, []*TransactionRecipient{tr}, traceId, extra, nil)
 }
 
 func SendTransferTransactionWithOutputs(ctx context.Context, assetId, receiver, amount string, utxos []*Output, traceId string, extra []byte, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).SendTransferTransactionWithOutputs(ctx, assetId, receiver, amount, utxos, traceId, extra)
+}
+
+func (c *Client) SendTransferTransactionWithOutputs(ctx context.Context, assetId, receiver, amount string, utxos []*Output, traceId string, extra []byte) (*SequencerTransactionRequest, error) {
 	if uuid.FromStringOrNil(receiver).String() != receiver {
 		return nil, fmt.Errorf("invalid receiver %s", receiver)
 	}
@@ -87,17 +100,22 @@ func SendTransferTransactionWithOutputs(ctx context.Context, assetId, receiver,
 		MixAddress: ma,
 		Amount:     amount,
 	}
-	return SendTransactionWithOutputs(ctx, assetId, []*TransactionRecipient{tr}, utxos, traceId, extra, nil, u)
+	return c.SendTransactionWithOutputs(ctx, assetId, []*TransactionRecipient{tr}, utxos, traceId, extra, nil)
 }
 
 func SendTransactionUntilSufficient(ctx context.Context, assetId, receiver, amount, traceId string, extra []byte, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).SendTransactionUntilSufficient(ctx, assetId, receiver, amount, traceId, extra)
+}
+
+func (c *Client) SendTransactionUntilSufficient(ctx context.Context, assetId, receiver, amount, traceId string, extra []byte) (*SequencerTransactionRequest, error) {
 	for {
-		str, err := SendTransferTransaction(ctx, assetId, receiver, amount, traceId, extra, u)
+		str, err := c.SendTransferTransaction(ctx, assetId, receiver, amount, traceId, extra)
 		if err == nil {
 			return str, nil
 		}
-		if ue, ok := err.(*UtxoInsufficientError); ok {
-			log.Println(ue)
+		var ue *UtxoInsufficientError
+		if errors.As(err, &ue) {
+			c.log().WarnContext(ctx, "send transaction until sufficient", "asset", assetId, "trace_id", traceId, "error", ue)
 			time.Sleep(2 * time.Second)
 		} else {
 			return nil, err
@@ -106,10 +124,18 @@ func SendTransactionUntilSufficient(ctx context.Context, assetId, receiver, amou
 }
 
 func SendTransactionWithChangeOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, splitAmount string, splitCount int, u *SafeUser) (*SequencerTransactionRequest, error) {
-	return SendTransactionWithUtxosAndChangeOutputs(ctx, assetId, recipients, traceId, extra, references, splitAmount, splitCount, nil, common.Zero, u)
+	return defaultClient.WithSafeUser(u).SendTransactionWithChangeOutputs(ctx, assetId, recipients, traceId, extra, references, splitAmount, splitCount)
+}
+
+func (c *Client) SendTransactionWithChangeOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, splitAmount string, splitCount int) (*SequencerTransactionRequest, error) {
+	return c.SendTransactionWithUtxosAndChangeOutputs(ctx, assetId, recipients, traceId, extra, references, splitAmount, splitCount, nil, common.Zero)
 }
 
 func SendTransactionWithUtxosAndChangeOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, splitAmount string, splitCount int, outputs []*Output, changeAmount common.Integer, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).SendTransactionWithUtxosAndChangeOutputs(ctx, assetId, recipients, traceId, extra, references, splitAmount, splitCount, outputs, changeAmount)
+}
+
+func (c *Client) SendTransactionWithUtxosAndChangeOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, splitAmount string, splitCount int, outputs []*Output, changeAmount common.Integer) (*SequencerTransactionRequest, error) {
 	splitAmt := common.NewIntegerFromString(splitAmount)
 	if uuid.FromStringOrNil(assetId).String() == assetId {
 		assetId = crypto.Sha256Hash([]byte(assetId)).String()
@@ -124,14 +150,14 @@ func SendTransactionWithUtxosAndChangeOutputs(ctx context.Context, assetId strin
 
 	if len(outputs) <= 0 {
 		// get unspent outputs for asset and may return insufficient outputs error
-		outputs, changeAmount, err = requestUnspentOutputsForRecipients(ctx, assetId, recipients, u)
+		outputs, changeAmount, err = c.requestUnspentOutputsForRecipients(ctx, assetId, recipients)
 		if err != nil {
-			return nil, fmt.Errorf("requestUnspentOutputsForRecipients(%s) => %v", assetId, err)
+			return nil, fmt.Errorf("requestUnspentOutputsForRecipients(%s) => %w", assetId, err)
 		}
 	}
 	// change to the sender
 	if changeAmount.Sign() > 0 {
-		ma := NewUUIDMixAddress([]string{u.UserId}, 1)
+		ma := NewUUIDMixAddress([]string{c.user.UserId}, 1)
 		if splitCount > 0 && splitAmt.Sign() > 0 && changeAmount.Cmp(splitAmt) > 0 {
 			if splitCount > (256 - len(recipients)) {
 				return nil, fmt.Errorf("invalid split count %d", splitCount)
@@ -175,14 +201,22 @@ func SendTransactionWithUtxosAndChangeOutputs(ctx context.Context, assetId strin
 			})
 		}
 	}
-	return sendTransaction(ctx, asset, outputs, recipients, traceId, extra, references, u)
+	return c.sendTransaction(ctx, asset, outputs, recipients, traceId, extra, references)
 }
 
 func SendTransaction(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, u *SafeUser) (*SequencerTransactionRequest, error) {
-	return SendTransactionWithChangeOutputs(ctx, assetId, recipients, traceId, extra, references, "0", 0, u)
+	return defaultClient.WithSafeUser(u).SendTransaction(ctx, assetId, recipients, traceId, extra, references)
+}
+
+func (c *Client) SendTransaction(ctx context.Context, assetId string, recipients []*TransactionRecipient, traceId string, extra []byte, references []string) (*SequencerTransactionRequest, error) {
+	return c.SendTransactionWithChangeOutputs(ctx, assetId, recipients, traceId, extra, references, "0", 0)
 }
 
 func SendTransactionWithOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, utxos []*Output, traceId string, extra []byte, references []string, u *SafeUser) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithSafeUser(u).SendTransactionWithOutputs(ctx, assetId, recipients, utxos, traceId, extra, references)
+}
+
+func (c *Client) SendTransactionWithOutputs(ctx context.Context, assetId string, recipients []*TransactionRecipient, utxos []*Output, traceId string, extra []byte, references []string) (*SequencerTransactionRequest, error) {
 	if uuid.FromStringOrNil(assetId).String() == assetId {
 		assetId = crypto.Sha256Hash([]byte(assetId)).String()
 	}
@@ -213,30 +247,35 @@ func SendTransactionWithOutputs(ctx context.Context, assetId string, recipients
 	}
 	changeAmount := totalInput.Sub(totalOutput)
 	if changeAmount.Sign() > 0 {
-		ma := NewUUIDMixAddress([]string{u.UserId}, 1)
+		ma := NewUUIDMixAddress([]string{c.user.UserId}, 1)
 		recipients = append(recipients, &TransactionRecipient{
 			MixAddress: ma,
 			Amount:     changeAmount.String(),
 		})
 	}
-	return sendTransaction(ctx, asset, utxos, recipients, traceId, extra, references, u)
+	return c.sendTransaction(ctx, asset, utxos, recipients, traceId, extra, references)
 }
 
 func GetTransactionById(ctx context.Context, requestId string) (*SequencerTransactionRequest, error) {
 	return GetTransactionByIdWithSafeUser(ctx, requestId, nil)
 }
 
-func GetTransactionByIdWithSafeUser(ctx context.Context, requestId string, su *SafeUser) (*SequencerTransactionRequest, error) {
+func GetTransactionByIdWithSafeUser(ctx context.Context, requestId string, auth Authorizer) (*SequencerTransactionRequest, error) {
+	return defaultClient.WithAuthorizer(auth).GetTransactionById(ctx, requestId)
+}
+
+// GetTransactionById signs the request only when the client has an authorizer.
+func (c *Client) GetTransactionById(ctx context.Context, requestId string) (*SequencerTransactionRequest, error) {
 	method, path := "GET", fmt.Sprintf("/safe/transactions/%s", requestId)
 	var accessToken string
 	var err error
-	if su != nil {
-		accessToken, err = SignAuthenticationToken(method, path, "", su)
+	if c.auth != nil {
+		accessToken, err = c.sign(method, path, "")
 		if err != nil {
 			return nil, err
 		}
 	}
-	body, err := Request(ctx, method, path, nil, accessToken)
+	body, err := c.Request(ctx, method, path, nil, accessToken)
 	if err != nil {
 		return nil, err
 	}
@@ -256,16 +295,29 @@ func GetTransactionByIdWithSafeUser(ctx context.Context, requestId string, su *S
 	return resp.Data, nil
 }
 
-func sendTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, recipients []*TransactionRecipient, traceId string, extra []byte, references []string, u *SafeUser) (*SequencerTransactionRequest, error) {
+func (c *Client) sendTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, recipients []*TransactionRecipient, traceId string, extra []byte, references []string) (_ *SequencerTransactionRequest, err error) {
+	ctx, span := c.telemetry.start(ctx, "sendTransaction", trace.SpanKindInternal,
+		attribute.String("mixin.asset", asset.String()),
+		attribute.String("mixin.trace_id", traceId),
+		attribute.Int("mixin.inputs", len(utxos)),
+		attribute.Int("mixin.recipients", len(recipients)))
+	defer func() { endSpan(span, err) }()
+
 	// build the unsigned raw transaction
-	tx, err := BuildRawTransaction(ctx, asset, utxos, recipients, extra, references, traceId, u)
+	phase, pspan := c.telemetry.start(ctx, "sendTransaction.build", trace.SpanKindInternal)
+	tx, err := c.BuildRawTransaction(phase, asset, utxos, recipients, extra, references, traceId)
+	endSpan(pspan, err)
 	if err != nil {
-		return nil, fmt.Errorf("BuildRawTransaction(%s) => %v", asset, err)
+		return nil, fmt.Errorf("BuildRawTransaction(%s) => %w", asset, err)
 	}
 	ver := tx.AsVersioned()
 	// verify the raw transaction, the same trace id may have been signed already
-	str, err := verifyRawTransactionBySequencer(ctx, traceId, ver, u)
-	if err != nil || str.State != "unspent" {
+	phase, pspan = c.telemetry.start(ctx, "sendTransaction.verify", trace.SpanKindInternal)
+	str, err := c.verifyRawTransactionBySequencer(phase, traceId, ver)
+	endSpan(pspan, err)
+	if err != nil {
+		return str, fmt.Errorf("verifyRawTransactionBySequencer(%s) => %w", traceId, err)
+	} else if str.State != "unspent" {
 		return str, fmt.Errorf("verifyRawTransactionBySequencer(%s) => %v", traceId, err)
 	}
 
@@ -273,15 +325,19 @@ func sendTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, re
 	if len(str.Views) != len(ver.Inputs) {
 		return nil, fmt.Errorf("invalid view keys count %d %d", len(str.Views), len(ver.Inputs))
 	}
-	ver, err = signRawTransaction(ver, str.Views, u.SpendPrivateKey, u.IsSpendPrivateSum)
+	_, pspan = c.telemetry.start(ctx, "sendTransaction.sign", trace.SpanKindInternal)
+	ver, err = signRawTransaction(ver, str.Views, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
+	endSpan(pspan, err)
 	if err != nil {
-		return nil, fmt.Errorf("signRawTransaction(%v) => %v", ver, err)
+		return nil, fmt.Errorf("signRawTransaction(%v) => %w", ver, err)
 	}
 
 	// send the raw transaction to the sequencer api
-	result, err := sendRawTransactionToSequencer(ctx, traceId, ver, u)
+	phase, pspan = c.telemetry.start(ctx, "sendTransaction.submit", trace.SpanKindInternal)
+	result, err := c.sendRawTransactionToSequencer(phase, traceId, ver)
+	endSpan(pspan, err)
 	if err != nil {
-		return nil, fmt.Errorf("sendRawTransactionToSequencer(%s) => %v", traceId, err)
+		return nil, fmt.Errorf("sendRawTransactionToSequencer(%s) => %w", traceId, err)
 	}
 	if hex.EncodeToString(ver.Marshal()) != result.RawTransaction {
 		panic(str.RawTransaction)
@@ -290,6 +346,10 @@ func sendTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, re
 }
 
 func BuildRawTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, recipients []*TransactionRecipient, extra []byte, references []string, traceId string, u *SafeUser) (*common.Transaction, error) {
+	return defaultClient.WithSafeUser(u).BuildRawTransaction(ctx, asset, utxos, recipients, extra, references, traceId)
+}
+
+func (c *Client) BuildRawTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output, recipients []*TransactionRecipient, extra []byte, references []string, traceId string) (*common.Transaction, error) {
 	tx := common.NewTransactionV5(asset)
 	for _, in := range utxos {
 		h, err := crypto.HashFromString(in.TransactionHash)
@@ -306,7 +366,9 @@ func BuildRawTransaction(ctx context.Context, asset crypto.Hash, utxos []*Output
 		tx.References = append(tx.References, rh)
 	}
 
-	gkm, err := RequestGhostRecipientsWithTraceId(ctx, recipients, traceId, u)
+	gctx, span := c.telemetry.start(ctx, "sendTransaction.ghost_keys", trace.SpanKindInternal)
+	gkm, err := c.RequestGhostRecipientsWithTraceId(gctx, recipients, traceId)
+	endSpan(span, err)
 	if err != nil {
 		return nil, err
 	}
@@ -352,17 +414,21 @@ type KernelTransactionRequestCreateRequest struct {
 	Raw       string `json:"raw"`
 }
 
-func VerifyRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest, u *SafeUser) ([]*SequencerTransactionRequest, error) {
+func VerifyRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest, auth Authorizer) ([]*SequencerTransactionRequest, error) {
+	return defaultClient.WithAuthorizer(auth).VerifyRawTransaction(ctx, requests)
+}
+
+func (c *Client) VerifyRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest) ([]*SequencerTransactionRequest, error) {
 	data, err := json.Marshal(requests)
 	if err != nil {
 		return nil, err
 	}
 	method, path := "POST", "/safe/transaction/requests"
-	token, err := SignAuthenticationToken(method, path, string(data), u)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -380,12 +446,12 @@ func VerifyRawTransaction(ctx context.Context, requests []*KernelTransactionRequ
 	return resp.Data, nil
 }
 
-func verifyRawTransactionBySequencer(ctx context.Context, traceId string, ver *common.VersionedTransaction, u *SafeUser) (*SequencerTransactionRequest, error) {
+func (c *Client) verifyRawTransactionBySequencer(ctx context.Context, traceId string, ver *common.VersionedTransaction) (*SequencerTransactionRequest, error) {
 	requests := []*KernelTransactionRequestCreateRequest{{
 		RequestID: traceId,
 		Raw:       hex.EncodeToString(ver.Marshal()),
 	}}
-	verified, err := VerifyRawTransaction(ctx, requests, u)
+	verified, err := c.VerifyRawTransaction(ctx, requests)
 	if err != nil {
 		return nil, err
 	}
@@ -437,17 +503,21 @@ func signRawTransaction(ver *common.VersionedTransaction, views []string, spendK
 	return ver, nil
 }
 
-func SendRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest, u *SafeUser) ([]*SequencerTransactionRequest, error) {
+func SendRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest, auth Authorizer) ([]*SequencerTransactionRequest, error) {
+	return defaultClient.WithAuthorizer(auth).SendRawTransaction(ctx, requests)
+}
+
+func (c *Client) SendRawTransaction(ctx context.Context, requests []*KernelTransactionRequestCreateRequest) ([]*SequencerTransactionRequest, error) {
 	data, err := json.Marshal(requests)
 	if err != nil {
 		return nil, err
 	}
 	method, path := "POST", "/safe/transactions"
-	token, err := SignAuthenticationToken(method, path, string(data), u)
+	token, err := c.sign(method, path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, method, path, data, token)
+	body, err := c.Request(ctx, method, path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -465,12 +535,12 @@ func SendRawTransaction(ctx context.Context, requests []*KernelTransactionReques
 	return resp.Data, nil
 }
 
-func sendRawTransactionToSequencer(ctx context.Context, traceId string, ver *common.VersionedTransaction, u *SafeUser) (*SequencerTransactionRequest, error) {
+func (c *Client) sendRawTransactionToSequencer(ctx context.Context, traceId string, ver *common.VersionedTransaction) (*SequencerTransactionRequest, error) {
 	requests := []*KernelTransactionRequestCreateRequest{{
 		RequestID: traceId,
 		Raw:       hex.EncodeToString(ver.Marshal()),
 	}}
-	txs, err := SendRawTransaction(ctx, requests, u)
+	txs, err := c.SendRawTransaction(ctx, requests)
 	if err != nil {
 		return nil, err
 	}
@@ -480,15 +550,15 @@ func sendRawTransactionToSequencer(ctx context.Context, traceId string, ver *com
 	return txs[0], nil
 }
 
-func requestUnspentOutputsForRecipients(ctx context.Context, assetId string, recipients []*TransactionRecipient, u *SafeUser) ([]*Output, common.Integer, error) {
+func (c *Client) requestUnspentOutputsForRecipients(ctx context.Context, assetId string, recipients []*TransactionRecipient) ([]*Output, common.Integer, error) {
 	var totalOutput common.Integer
 	for _, r := range recipients {
 		amt := common.NewIntegerFromString(r.Amount)
 		totalOutput = totalOutput.Add(amt)
 	}
 
-	membersHash := HashMembers([]string{u.UserId})
-	unspentOutputs, err := ListOutputs(ctx, membersHash, 1, assetId, "unspent", 0, 250, u)
+	membersHash := HashMembers([]string{c.user.UserId})
+	unspentOutputs, err := c.ListOutputs(ctx, membersHash, 1, assetId, "unspent", 0, 250)
 	if err != nil {
 		return nil, common.Zero, err
 	}
@@ -517,8 +587,12 @@ func requestUnspentOutputsForRecipients(ctx context.Context, assetId string, rec
 }
 
 func RequestGhostRecipientsWithTraceId(ctx context.Context, recipients []*TransactionRecipient, traceId string, u *SafeUser) (map[int]*GhostKeys, error) {
+	return defaultClient.WithSafeUser(u).RequestGhostRecipientsWithTraceId(ctx, recipients, traceId)
+}
+
+func (c *Client) RequestGhostRecipientsWithTraceId(ctx context.Context, recipients []*TransactionRecipient, traceId string) (map[int]*GhostKeys, error) {
 	traceHash := crypto.Blake3Hash([]byte(traceId))
-	privSpend, err := crypto.KeyFromString(u.SpendPrivateKey)
+	privSpend, err := crypto.KeyFromString(c.user.SpendPrivateKey)
 	if err != nil {
 		panic(err)
 	}
@@ -552,7 +626,7 @@ func RequestGhostRecipientsWithTraceId(ctx context.Context, recipients []*Transa
 		}
 	}
 	if len(uuidGkrs) > 0 {
-		uuidGks, err := RequestSafeGhostKeys(ctx, uuidGkrs, u)
+		uuidGks, err := c.RequestSafeGhostKeys(ctx, uuidGkrs)
 		if err != nil {
 			return nil, err
 		}
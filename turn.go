@@ -11,12 +11,16 @@ type Turn struct {
 	Credential string `json:"credential"`
 }
 
-func GetTurnServer(ctx context.Context, su *SafeUser) ([]*Turn, error) {
-	token, err := SignAuthenticationToken("GET", "/turn", "", su)
+func GetTurnServer(ctx context.Context, auth Authorizer) ([]*Turn, error) {
+	return defaultClient.WithAuthorizer(auth).GetTurnServer(ctx)
+}
+
+func (c *Client) GetTurnServer(ctx context.Context) ([]*Turn, error) {
+	token, err := c.sign("GET", "/turn", "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", "/turn", nil, token)
+	body, err := c.Request(ctx, "GET", "/turn", nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
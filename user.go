@@ -61,11 +61,15 @@ const (
 )
 
 func CreateUserSimple(ctx context.Context, sessionPublicKey, fullName string) (*User, error) {
+	return defaultClient.CreateUserSimple(ctx, sessionPublicKey, fullName)
+}
+
+func (c *Client) CreateUserSimple(ctx context.Context, sessionPublicKey, fullName string) (*User, error) {
 	data, _ := json.Marshal(map[string]string{
 		"session_secret": sessionPublicKey,
 		"full_name":      fullName,
 	})
-	body, err := SimpleRequest(ctx, "POST", "/users", data)
+	body, err := c.SimpleRequest(ctx, "POST", "/users", data)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -83,16 +87,20 @@ func CreateUserSimple(ctx context.Context, sessionPublicKey, fullName string) (*
 	return resp.Data, nil
 }
 
-func CreateUser(ctx context.Context, sessionSecret, fullName string, su *SafeUser) (*User, error) {
+func CreateUser(ctx context.Context, sessionSecret, fullName string, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).CreateUser(ctx, sessionSecret, fullName)
+}
+
+func (c *Client) CreateUser(ctx context.Context, sessionSecret, fullName string) (*User, error) {
 	data, _ := json.Marshal(map[string]string{
 		"session_secret": sessionSecret,
 		"full_name":      fullName,
 	})
-	token, err := SignAuthenticationToken("POST", "/users", string(data), su)
+	token, err := c.sign("POST", "/users", string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", "/users", data, token)
+	body, err := c.Request(ctx, "POST", "/users", data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -110,13 +118,17 @@ func CreateUser(ctx context.Context, sessionSecret, fullName string, su *SafeUse
 	return resp.Data, nil
 }
 
-func GetUser(ctx context.Context, userId string, su *SafeUser) (*User, error) {
+func GetUser(ctx context.Context, userId string, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).GetUser(ctx, userId)
+}
+
+func (c *Client) GetUser(ctx context.Context, userId string) (*User, error) {
 	url := fmt.Sprintf("/users/%s", userId)
-	token, err := SignAuthenticationToken("GET", url, "", su)
+	token, err := c.sign("GET", url, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", url, nil, token)
+	body, err := c.Request(ctx, "GET", url, nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -134,14 +146,18 @@ func GetUser(ctx context.Context, userId string, su *SafeUser) (*User, error) {
 	return resp.Data, nil
 }
 
-func GetUsers(ctx context.Context, userIds []string, su *SafeUser) ([]*User, error) {
+func GetUsers(ctx context.Context, userIds []string, auth Authorizer) ([]*User, error) {
+	return defaultClient.WithAuthorizer(auth).GetUsers(ctx, userIds)
+}
+
+func (c *Client) GetUsers(ctx context.Context, userIds []string) ([]*User, error) {
 	url := "/users/fetch"
 	data, _ := json.Marshal(userIds)
-	token, err := SignAuthenticationToken("POST", url, string(data), su)
+	token, err := c.sign("POST", url, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", url, data, token)
+	body, err := c.Request(ctx, "POST", url, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -159,13 +175,17 @@ func GetUsers(ctx context.Context, userIds []string, su *SafeUser) ([]*User, err
 	return resp.Data, nil
 }
 
-func SearchUser(ctx context.Context, query string, su *SafeUser) (*User, error) {
+func SearchUser(ctx context.Context, query string, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).SearchUser(ctx, query)
+}
+
+func (c *Client) SearchUser(ctx context.Context, query string) (*User, error) {
 	url := fmt.Sprintf("/search/%s", query)
-	token, err := SignAuthenticationToken("GET", url, "", su)
+	token, err := c.sign("GET", url, "")
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "GET", url, nil, token)
+	body, err := c.Request(ctx, "GET", url, nil, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -184,7 +204,11 @@ func SearchUser(ctx context.Context, query string, su *SafeUser) (*User, error)
 }
 
 func UpdateTipPin(ctx context.Context, pin, pubTip string, su *SafeUser) error {
-	oldEncryptedPin, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), su)
+	return defaultClient.WithSafeUser(su).UpdateTipPin(ctx, pin, pubTip)
+}
+
+func (c *Client) UpdateTipPin(ctx context.Context, pin, pubTip string) error {
+	oldEncryptedPin, err := EncryptEd25519PIN(pin, uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return err
 	}
@@ -200,25 +224,29 @@ func UpdateTipPin(ctx context.Context, pin, pubTip string, su *SafeUser) error {
 	counter := make([]byte, 8)
 	binary.BigEndian.PutUint64(counter, 1)
 	pubTipBuf = append(pubTipBuf, counter...)
-	encryptedPin, err := EncryptEd25519PIN(hex.EncodeToString(pubTipBuf), uint64(time.Now().UnixNano()), su)
+	encryptedPin, err := EncryptEd25519PIN(hex.EncodeToString(pubTipBuf), uint64(time.Now().UnixNano()), c.user)
 	if err != nil {
 		return err
 	}
 
-	return UpdatePin(ctx, oldEncryptedPin, encryptedPin, su)
+	return c.UpdatePin(ctx, oldEncryptedPin, encryptedPin)
+}
+
+func UpdatePin(ctx context.Context, oldEncryptedPin, encryptedPin string, auth Authorizer) error {
+	return defaultClient.WithAuthorizer(auth).UpdatePin(ctx, oldEncryptedPin, encryptedPin)
 }
 
-func UpdatePin(ctx context.Context, oldEncryptedPin, encryptedPin string, su *SafeUser) error {
+func (c *Client) UpdatePin(ctx context.Context, oldEncryptedPin, encryptedPin string) error {
 	data, _ := json.Marshal(map[string]string{
 		"old_pin_base64": oldEncryptedPin,
 		"pin_base64":     encryptedPin,
 	})
 
-	token, err := SignAuthenticationToken("POST", "/pin/update", string(data), su)
+	token, err := c.sign("POST", "/pin/update", string(data))
 	if err != nil {
 		return err
 	}
-	body, err := Request(ctx, "POST", "/pin/update", data, token)
+	body, err := c.Request(ctx, "POST", "/pin/update", data, token)
 	if err != nil {
 		return ServerError(ctx, err)
 	}
@@ -235,8 +263,18 @@ func UpdatePin(ctx context.Context, oldEncryptedPin, encryptedPin string, su *Sa
 	return nil
 }
 
+// Deprecated: use RequestUserMe with BearerToken(accessToken).
 func UserMeWithRequestID(ctx context.Context, accessToken, requestID string) (*UserMeView, error) {
-	body, err := RequestWithId(ctx, "GET", "/safe/me", nil, accessToken, requestID)
+	return defaultClient.WithAuthorizer(BearerToken(accessToken)).requestUserMe(ctx, requestID)
+}
+
+func (c *Client) requestUserMe(ctx context.Context, requestID string) (*UserMeView, error) {
+	path := "/safe/me"
+	token, err := c.sign("GET", path, "")
+	if err != nil {
+		return nil, err
+	}
+	body, err := c.RequestWithId(ctx, "GET", path, nil, token, requestID)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -254,20 +292,29 @@ func UserMeWithRequestID(ctx context.Context, accessToken, requestID string) (*U
 	return resp.Data, nil
 }
 
+// Deprecated: use RequestUserMe with BearerToken(accessToken).
 func UserMe(ctx context.Context, accessToken string) (*UserMeView, error) {
-	return UserMeWithRequestID(ctx, accessToken, UuidNewV4().String())
+	return RequestUserMe(ctx, BearerToken(accessToken))
 }
 
-func RequestUserMe(ctx context.Context, su *SafeUser) (*UserMeView, error) {
-	path := "/safe/me"
-	token, err := SignAuthenticationToken("GET", path, "", su)
-	if err != nil {
-		return nil, err
-	}
-	return UserMe(ctx, token)
+// Deprecated: use RequestUserMe on a client made by WithAuthorizer(BearerToken(accessToken)).
+func (c *Client) UserMe(ctx context.Context, accessToken string) (*UserMeView, error) {
+	return c.WithAuthorizer(BearerToken(accessToken)).RequestUserMe(ctx)
+}
+
+func RequestUserMe(ctx context.Context, auth Authorizer) (*UserMeView, error) {
+	return defaultClient.WithAuthorizer(auth).RequestUserMe(ctx)
 }
 
-func UpdateUserMe(ctx context.Context, fullName, avatarBase64 string, su *SafeUser) (*User, error) {
+func (c *Client) RequestUserMe(ctx context.Context) (*UserMeView, error) {
+	return c.requestUserMe(ctx, UuidNewV4().String())
+}
+
+func UpdateUserMe(ctx context.Context, fullName, avatarBase64 string, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).UpdateUserMe(ctx, fullName, avatarBase64)
+}
+
+func (c *Client) UpdateUserMe(ctx context.Context, fullName, avatarBase64 string) (*User, error) {
 	data, err := json.Marshal(map[string]any{
 		"full_name":     fullName,
 		"avatar_base64": avatarBase64,
@@ -277,11 +324,11 @@ func UpdateUserMe(ctx context.Context, fullName, avatarBase64 string, su *SafeUs
 	}
 
 	path := "/me"
-	token, err := SignAuthenticationToken("POST", path, string(data), su)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -299,7 +346,11 @@ func UpdateUserMe(ctx context.Context, fullName, avatarBase64 string, su *SafeUs
 	return resp.Data, nil
 }
 
-func UpdatePreference(ctx context.Context, messageSource, conversationSource, currency string, threshold float64, su *SafeUser) (*User, error) {
+func UpdatePreference(ctx context.Context, messageSource, conversationSource, currency string, threshold float64, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).UpdatePreference(ctx, messageSource, conversationSource, currency, threshold)
+}
+
+func (c *Client) UpdatePreference(ctx context.Context, messageSource, conversationSource, currency string, threshold float64) (*User, error) {
 	data, err := json.Marshal(map[string]any{
 		"receive_message_source":          messageSource,
 		"accept_conversation_source":      conversationSource,
@@ -310,11 +361,11 @@ func UpdatePreference(ctx context.Context, messageSource, conversationSource, cu
 		return nil, err
 	}
 	path := "/me/preferences"
-	token, err := SignAuthenticationToken("POST", path, string(data), su)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -332,7 +383,11 @@ func UpdatePreference(ctx context.Context, messageSource, conversationSource, cu
 	return resp.Data, nil
 }
 
-func Relationship(ctx context.Context, userId, action string, su *SafeUser) (*User, error) {
+func Relationship(ctx context.Context, userId, action string, auth Authorizer) (*User, error) {
+	return defaultClient.WithAuthorizer(auth).Relationship(ctx, userId, action)
+}
+
+func (c *Client) Relationship(ctx context.Context, userId, action string) (*User, error) {
 	data, err := json.Marshal(map[string]any{
 		"user_id": userId,
 		"action":  action,
@@ -342,11 +397,11 @@ func Relationship(ctx context.Context, userId, action string, su *SafeUser) (*Us
 	}
 
 	path := "/relationships"
-	token, err := SignAuthenticationToken("POST", path, string(data), su)
+	token, err := c.sign("POST", path, string(data))
 	if err != nil {
 		return nil, err
 	}
-	body, err := Request(ctx, "POST", path, data, token)
+	body, err := c.Request(ctx, "POST", path, data, token)
 	if err != nil {
 		return nil, ServerError(ctx, err)
 	}
@@ -16,19 +16,25 @@ const (
 // SendWithdrawal sends a withdrawal request to the Mixin Network.
 // preferAssetFeeOverChainFee is used to determine whether to use the asset fee or the chain fee.
 func SendWithdrawal(ctx context.Context, assetId, destination, tag, amount, traceId string, preferAssetFeeOverChainFee bool, memo string, u *SafeUser) ([]*SequencerTransactionRequest, error) {
-	asset, err := ReadAsset(ctx, assetId)
+	return defaultClient.WithSafeUser(u).SendWithdrawal(ctx, assetId, destination, tag, amount, traceId, preferAssetFeeOverChainFee, memo)
+}
+
+// SendWithdrawal sends a withdrawal request to the Mixin Network.
+// preferAssetFeeOverChainFee is used to determine whether to use the asset fee or the chain fee.
+func (c *Client) SendWithdrawal(ctx context.Context, assetId, destination, tag, amount, traceId string, preferAssetFeeOverChainFee bool, memo string) ([]*SequencerTransactionRequest, error) {
+	asset, err := c.ReadAsset(ctx, assetId)
 	if err != nil {
 		return nil, err
 	}
 	chain := asset
 	if asset.ChainID != asset.AssetID {
-		chain, err = ReadAsset(ctx, asset.ChainID)
+		chain, err = c.ReadAsset(ctx, asset.ChainID)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	fees, err := ReadAssetFee(ctx, assetId, destination, u)
+	fees, err := c.ReadAssetFee(ctx, assetId, destination)
 	if err != nil {
 		return nil, err
 	}
@@ -40,14 +46,18 @@ func SendWithdrawal(ctx context.Context, assetId, destination, tag, amount, trac
 		}
 	}
 
-	return withdrawalTransaction(ctx, traceId, MixinFeeUserId, fee.AssetID, fee.Amount, assetId, destination, tag, memo, amount, nil, nil, u)
+	return c.withdrawalTransaction(ctx, traceId, MixinFeeUserId, fee.AssetID, fee.Amount, assetId, destination, tag, memo, amount, nil, nil)
 }
 
 func WithdrawalWithUtxos(ctx context.Context, traceId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount string, utxos, feeUtxos []*Output, u *SafeUser) ([]*SequencerTransactionRequest, error) {
-	return withdrawalTransaction(ctx, traceId, MixinFeeUserId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount, utxos, feeUtxos, u)
+	return defaultClient.WithSafeUser(u).WithdrawalWithUtxos(ctx, traceId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount, utxos, feeUtxos)
+}
+
+func (c *Client) WithdrawalWithUtxos(ctx context.Context, traceId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount string, utxos, feeUtxos []*Output) ([]*SequencerTransactionRequest, error) {
+	return c.withdrawalTransaction(ctx, traceId, MixinFeeUserId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount, utxos, feeUtxos)
 }
 
-func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, feeAssetId string, feeAmount, assetId, destination, tag, memo, amount string, utxos, feeUtxos []*Output, u *SafeUser) ([]*SequencerTransactionRequest, error) {
+func (c *Client) withdrawalTransaction(ctx context.Context, traceId, feeReceiverId, feeAssetId, feeAmount, assetId, destination, tag, memo, amount string, utxos, feeUtxos []*Output) ([]*SequencerTransactionRequest, error) {
 	if feeAssetId == assetId {
 		recipients := []*TransactionRecipient{{
 			Amount:      amount,
@@ -57,7 +67,7 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 			Amount:     feeAmount,
 			MixAddress: NewUUIDMixAddress([]string{feeReceiverId}, 1),
 		}}
-		tx, err := SendTransaction(ctx, assetId, recipients, traceId, []byte(memo), nil, u)
+		tx, err := c.SendTransaction(ctx, assetId, recipients, traceId, []byte(memo), nil)
 		if err != nil {
 			return nil, err
 		}
@@ -68,7 +78,7 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 	feeTraceId := UniqueObjectId(traceId, "FEE")
 	feeAsset := crypto.Sha256Hash([]byte(feeAssetId))
 
-	membersHash := HashMembers([]string{u.UserId})
+	membersHash := HashMembers([]string{c.user.UserId})
 	recipients := []*TransactionRecipient{{
 		Amount:      amount,
 		Destination: destination,
@@ -77,7 +87,7 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 	totalOutput := common.NewIntegerFromString(amount)
 	var err error
 	if len(utxos) < 1 {
-		utxos, err = ListOutputs(ctx, membersHash, 1, assetId, OutputStateUnspent, 0, 250, u)
+		utxos, err = c.ListOutputs(ctx, membersHash, 1, assetId, OutputStateUnspent, 0, 250)
 		if err != nil {
 			return nil, err
 		}
@@ -107,7 +117,7 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 	if change.Sign() > 0 {
 		recipients = append(recipients, &TransactionRecipient{
 			Amount:     change.String(),
-			MixAddress: NewUUIDMixAddress([]string{u.UserId}, 1),
+			MixAddress: NewUUIDMixAddress([]string{c.user.UserId}, 1),
 		})
 	}
 
@@ -118,7 +128,7 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 
 	var feeChange common.Integer
 	if len(feeUtxos) < 1 {
-		feeUtxos, err = ListOutputs(ctx, membersHash, 1, feeAssetId, OutputStateUnspent, 0, 250, u)
+		feeUtxos, err = c.ListOutputs(ctx, membersHash, 1, feeAssetId, OutputStateUnspent, 0, 250)
 		if err != nil {
 			return nil, err
 		}
@@ -151,28 +161,28 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 	if feeChange.Sign() > 0 {
 		feeRecipients = append(feeRecipients, &TransactionRecipient{
 			Amount:     feeChange.String(),
-			MixAddress: NewUUIDMixAddress([]string{u.UserId}, 1),
+			MixAddress: NewUUIDMixAddress([]string{c.user.UserId}, 1),
 		})
 	}
 
-	transaction, err := BuildRawTransaction(ctx, asset, unspentOutputs, recipients, []byte(memo), nil, traceId, u)
+	transaction, err := c.BuildRawTransaction(ctx, asset, unspentOutputs, recipients, []byte(memo), nil, traceId)
 	if err != nil {
 		return nil, fmt.Errorf("BuildRawTransaction(%s): %w", asset, err)
 	}
 	ver := transaction.AsVersioned()
-	feeTransaction, err := BuildRawTransaction(ctx, feeAsset, unspentFeeOutputs, feeRecipients, []byte(memo), []string{crypto.Blake3Hash(ver.Marshal()).String()}, feeTraceId, u)
+	feeTransaction, err := c.BuildRawTransaction(ctx, feeAsset, unspentFeeOutputs, feeRecipients, []byte(memo), []string{crypto.Blake3Hash(ver.Marshal()).String()}, feeTraceId)
 	if err != nil {
 		return nil, fmt.Errorf("buildFeeRawTransaction(%s): %w", feeAsset, err)
 	}
 	feeVer := feeTransaction.AsVersioned()
 
-	requests, err := VerifyRawTransaction(ctx, []*KernelTransactionRequestCreateRequest{{
+	requests, err := c.VerifyRawTransaction(ctx, []*KernelTransactionRequestCreateRequest{{
 		RequestID: traceId,
 		Raw:       hex.EncodeToString(ver.Marshal()),
 	}, {
 		RequestID: feeTraceId,
 		Raw:       hex.EncodeToString(feeVer.Marshal()),
-	}}, u)
+	}})
 	if err != nil {
 		return nil, err
 	} else if len(requests) != 2 {
@@ -200,21 +210,21 @@ func withdrawalTransaction(ctx context.Context, traceId, feeReceiverId string, f
 		return nil, fmt.Errorf("invalid fee inputs count %d/%d", len(feeStr.Views), len(feeVer.Inputs))
 	}
 
-	ver, err = signRawTransaction(ver, str.Views, u.SpendPrivateKey, u.IsSpendPrivateSum)
+	ver, err = signRawTransaction(ver, str.Views, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		return nil, fmt.Errorf("signRawTransaction(%s): %w", asset, err)
 	}
-	feeVer, err = signRawTransaction(feeVer, feeStr.Views, u.SpendPrivateKey, u.IsSpendPrivateSum)
+	feeVer, err = signRawTransaction(feeVer, feeStr.Views, c.user.SpendPrivateKey, c.user.IsSpendPrivateSum)
 	if err != nil {
 		return nil, fmt.Errorf("signFeeRawTransaction(%s): %w", feeAsset, err)
 	}
-	results, err := SendRawTransaction(ctx, []*KernelTransactionRequestCreateRequest{{
+	results, err := c.SendRawTransaction(ctx, []*KernelTransactionRequestCreateRequest{{
 		RequestID: traceId,
 		Raw:       hex.EncodeToString(ver.Marshal()),
 	}, {
 		RequestID: feeTraceId,
 		Raw:       hex.EncodeToString(feeVer.Marshal()),
-	}}, u)
+	}})
 	if err != nil {
 		return nil, fmt.Errorf("SendRawTransaction(%s): %w", traceId, err)
 	}